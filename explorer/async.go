@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorer
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"gioui.org/layout"
+)
+
+// Request identifies an asynchronous dialog. A nil Explorer returns the zero
+// Request, and never reports a result for it.
+type Request uint64
+
+// Event reports the result of an asynchronous dialog.
+type Event struct {
+	// Request identifies the dialog.
+	Request Request
+	// Files are the chosen files of ChooseFileAsync and ChooseFilesAsync.
+	Files []io.ReadCloser
+	// File is the created file of CreateFileAsync.
+	File io.WriteCloser
+	// Folder is the chosen folder of ChooseFolderAsync.
+	Folder string
+	// Err is the error of the dialog, such as ErrUserDecline, or
+	// context.Canceled if the request was cancelled.
+	Err error
+}
+
+// async holds the state of the asynchronous dialogs of an Explorer.
+type async struct {
+	mutex   sync.Mutex
+	next    Request
+	pending map[Request]context.CancelFunc
+	events  []Event
+}
+
+// ChooseFileAsync is like ChooseFileWithOptions, but returns immediately. The
+// result is reported by Update as an Event with a single file.
+func (e *Explorer) ChooseFileAsync(opts Options) Request {
+	return e.start(func(ctx context.Context) Event {
+		f, err := e.ChooseFileContext(ctx, opts)
+		if err != nil {
+			return Event{Err: err}
+		}
+		return Event{Files: []io.ReadCloser{f}}
+	})
+}
+
+// ChooseFilesAsync is like ChooseFilesWithOptions, but returns immediately.
+// The result is reported by Update.
+func (e *Explorer) ChooseFilesAsync(opts Options) Request {
+	return e.start(func(ctx context.Context) Event {
+		files, err := e.ChooseFilesContext(ctx, opts)
+		return Event{Files: files, Err: err}
+	})
+}
+
+// CreateFileAsync is like CreateFileWithOptions, but returns immediately. The
+// result is reported by Update.
+func (e *Explorer) CreateFileAsync(opts Options) Request {
+	return e.start(func(ctx context.Context) Event {
+		f, err := e.CreateFileContext(ctx, opts)
+		return Event{File: f, Err: err}
+	})
+}
+
+// ChooseFolderAsync is like ChooseFolderWithOptions, but returns immediately.
+// The result is reported by Update.
+func (e *Explorer) ChooseFolderAsync(opts Options) Request {
+	return e.start(func(ctx context.Context) Event {
+		folder, err := e.ChooseFolderContext(ctx, opts)
+		return Event{Folder: folder, Err: err}
+	})
+}
+
+// Cancel cancels the asynchronous dialog identified by r, if it's still
+// pending. Its Event reports context.Canceled, unless the dialog completed
+// first.
+func (e *Explorer) Cancel(r Request) {
+	if e == nil {
+		return
+	}
+	e.async.mutex.Lock()
+	cancel := e.async.pending[r]
+	e.async.mutex.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Update returns the next result of an asynchronous dialog. Results are
+// reported in the order the dialogs complete, and the window of the
+// Explorer is invalidated whenever one is ready.
+func (e *Explorer) Update(gtx layout.Context) (Event, bool) {
+	if e == nil {
+		return Event{}, false
+	}
+	e.async.mutex.Lock()
+	defer e.async.mutex.Unlock()
+	if len(e.async.events) == 0 {
+		return Event{}, false
+	}
+	ev := e.async.events[0]
+	e.async.events[0] = Event{}
+	e.async.events = e.async.events[1:]
+	return ev, true
+}
+
+// start runs work, which shows a dialog, in a new goroutine and queues its
+// result for Update.
+func (e *Explorer) start(work func(ctx context.Context) Event) Request {
+	if e == nil {
+		return 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &e.async
+	a.mutex.Lock()
+	a.next++
+	r := a.next
+	if a.pending == nil {
+		a.pending = make(map[Request]context.CancelFunc)
+	}
+	a.pending[r] = cancel
+	a.mutex.Unlock()
+
+	go func() {
+		ev := work(ctx)
+		ev.Request = r
+		cancel()
+		a.mutex.Lock()
+		delete(a.pending, r)
+		a.events = append(a.events, ev)
+		a.mutex.Unlock()
+		e.invalidate()
+	}()
+	return r
+}
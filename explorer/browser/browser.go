@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package browser implements an in-app file browser: a Gio widget that shows
+// file dialogs in a component.ModalLayer, for platforms without dialogs, such
+// as Linux systems without a running xdg-desktop-portal. It supports
+// breadcrumbs, filters, multiple selection, naming new files and keyboard
+// navigation.
+//
+// A Browser implements explorer.Dialogs, so it can be used on its own, or as
+// the fallback of an explorer.Explorer:
+//
+//	b := browser.New(window, modal)
+//	expl.SetFallback(b, explorer.FallbackAuto)
+//
+//	// In every frame:
+//	b.Update(gtx)
+//	modal.Layout(gtx, theme)
+//
+// Files are opened by their path, which isn't possible on Android.
+package browser
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"gioui.org/app"
+	"gioui.org/layout"
+	"gioui.org/widget/material"
+	"gioui.org/x/component"
+	"gioui.org/x/explorer"
+)
+
+// Browser shows file dialogs in a modal layer. Its dialogs block until the
+// user accepts or declines them, like the dialogs of explorer.Explorer, and
+// are shown one at a time.
+type Browser struct {
+	window *app.Window
+	modal  *component.ModalLayer
+	// lock is held while a dialog is shown. It's a channel rather than a
+	// sync.Mutex so that waiting for it can be cancelled.
+	lock chan struct{}
+
+	// mutex protects pending, which is set by the goroutines showing
+	// dialogs.
+	mutex sync.Mutex
+	// pending is the request waiting to be shown by Update.
+	pending *request
+
+	view view
+}
+
+var _ explorer.Dialogs = (*Browser)(nil)
+
+// request asks the view to choose files.
+type request struct {
+	ctx   context.Context
+	kind  kind
+	multi bool
+	opts  explorer.Options
+	// result receives the result of the request. It's buffered, so that
+	// the view never waits for an abandoned request.
+	result chan result
+}
+
+type result struct {
+	paths []string
+	err   error
+}
+
+type kind uint8
+
+const (
+	kindOpen kind = iota
+	kindSave
+	kindFolder
+)
+
+// New returns a Browser drawn when modal is laid out. The window w, which may
+// be nil, is invalidated when a dialog is requested, so that Update shows it
+// without waiting for the next frame.
+func New(w *app.Window, modal *component.ModalLayer) *Browser {
+	return &Browser{
+		window: w,
+		modal:  modal,
+		lock:   make(chan struct{}, 1),
+	}
+}
+
+// ChooseFileContext shows the browser to choose a single file, and returns
+// ctx.Err() once ctx is done.
+func (b *Browser) ChooseFileContext(ctx context.Context, opts explorer.Options) (io.ReadCloser, error) {
+	files, err := b.chooseFiles(ctx, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+}
+
+// ChooseFilesContext shows the browser to choose files, and returns
+// ctx.Err() once ctx is done.
+func (b *Browser) ChooseFilesContext(ctx context.Context, opts explorer.Options) ([]io.ReadCloser, error) {
+	return b.chooseFiles(ctx, opts, true)
+}
+
+func (b *Browser) chooseFiles(ctx context.Context, opts explorer.Options, multi bool) ([]io.ReadCloser, error) {
+	paths, err := b.browse(ctx, request{kind: kindOpen, multi: multi, opts: opts})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]io.ReadCloser, 0, len(paths))
+	for _, path := range paths {
+		f, err := explorer.OpenPath(path)
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// ChooseFolderContext shows the browser to choose a folder, and returns
+// ctx.Err() once ctx is done.
+func (b *Browser) ChooseFolderContext(ctx context.Context, opts explorer.Options) (string, error) {
+	paths, err := b.browse(ctx, request{kind: kindFolder, opts: opts})
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// CreateFileContext shows the browser to choose the location of a new file,
+// and returns ctx.Err() once ctx is done.
+func (b *Browser) CreateFileContext(ctx context.Context, opts explorer.Options) (io.WriteCloser, error) {
+	paths, err := b.browse(ctx, request{kind: kindSave, opts: opts})
+	if err != nil {
+		return nil, err
+	}
+	return explorer.CreatePath(paths[0], opts)
+}
+
+// ReadFile opens the file at the path uri.
+func (b *Browser) ReadFile(uri string) (io.ReadCloser, error) {
+	return explorer.OpenPath(uri)
+}
+
+// browse shows the view for req and waits for the chosen paths, or for ctx
+// to be done.
+func (b *Browser) browse(ctx context.Context, req request) ([]string, error) {
+	select {
+	case b.lock <- struct{}{}:
+		defer func() { <-b.lock }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	req.ctx = ctx
+	req.result = make(chan result, 1)
+	b.mutex.Lock()
+	b.pending = &req
+	b.mutex.Unlock()
+	b.invalidate()
+
+	select {
+	case res := <-req.result:
+		return res.paths, res.err
+	case <-ctx.Done():
+		b.mutex.Lock()
+		if b.pending == &req {
+			b.pending = nil
+		}
+		b.mutex.Unlock()
+		// Let Update hide the view.
+		b.invalidate()
+		return nil, ctx.Err()
+	}
+}
+
+// Update shows the browser for a requested dialog, and hides it once the
+// dialog is abandoned. It must be called every frame while dialogs may be
+// shown, before the modal layer is laid out. Dismissing the modal declines
+// the dialog.
+func (b *Browser) Update(gtx layout.Context) {
+	b.mutex.Lock()
+	req := b.pending
+	b.pending = nil
+	b.mutex.Unlock()
+	v := &b.view
+	if v.req != nil && v.req.ctx.Err() != nil {
+		v.req = nil
+		hide(b.modal, gtx.Now)
+	}
+	if req == nil {
+		return
+	}
+	if v.req != nil {
+		// Dialogs are serialized, so the shown request was abandoned.
+		v.req.result <- result{err: explorer.ErrUserDecline}
+	}
+	v.open(req)
+	b.modal.Widget = func(gtx layout.Context, th *material.Theme, anim *component.VisibilityAnimation) layout.Dimensions {
+		if v.req == nil {
+			return layout.Dimensions{}
+		}
+		if anim.State == component.Disappearing || !anim.Visible() {
+			// The modal was dismissed.
+			v.finish(nil, explorer.ErrUserDecline)
+			return layout.Dimensions{}
+		}
+		dims := v.Layout(gtx, th)
+		if v.req == nil {
+			hide(b.modal, gtx.Now)
+		}
+		return dims
+	}
+	if b.modal.State == component.Disappearing {
+		// Show the view again right away.
+		b.modal.State = component.Invisible
+	}
+	b.modal.Appear(gtx.Now)
+}
+
+// hide hides modal, even while it's appearing.
+func hide(modal *component.ModalLayer, now time.Time) {
+	if modal.State == component.Appearing {
+		modal.State = component.Visible
+	}
+	modal.Disappear(now)
+}
+
+func (b *Browser) invalidate() {
+	if b.window != nil {
+		b.window.Invalidate()
+	}
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package browser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/x/component"
+	"gioui.org/x/explorer"
+)
+
+// newTree creates a folder with a subfolder, files of several types and a
+// hidden file, and returns its path.
+func newTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "C.PNG", "d.png", ".hidden", filepath.Join("a", "inner.txt")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// openView returns a view showing req.
+func openView(req request) *view {
+	req.ctx = context.Background()
+	req.result = make(chan result, 1)
+	v := new(view)
+	v.open(&req)
+	return v
+}
+
+// names returns the names of the entries of v.
+func names(v *view) []string {
+	var names []string
+	for _, e := range v.entries {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+func index(t *testing.T, v *view, name string) int {
+	t.Helper()
+	i := slices.Index(names(v), name)
+	if i < 0 {
+		t.Fatalf("no entry %q in %v", name, names(v))
+	}
+	return i
+}
+
+func TestNavigation(t *testing.T) {
+	dir := newTree(t)
+	v := openView(request{kind: kindOpen, opts: explorer.Options{Folder: dir}})
+	if got, want := names(v), []string{"a", "b.txt", "C.PNG", "d.png"}; !slices.Equal(got, want) {
+		t.Errorf("listed %v, want folders first and no hidden files %v", got, want)
+	}
+
+	v.activate(index(t, v, "a"))
+	if want := filepath.Join(dir, "a"); v.dir != want {
+		t.Errorf("activating a folder opened %q, want %q", v.dir, want)
+	}
+	if got, want := names(v), []string{"inner.txt"}; !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+
+	v.key(key.Event{Name: key.NameDeleteBackward, State: key.Press})
+	if v.dir != dir {
+		t.Errorf("backspace opened %q, want the parent %q", v.dir, dir)
+	}
+
+	paths := crumbs(filepath.Join(dir, "a"))
+	if got, want := paths[len(paths)-2:], []string{dir, filepath.Join(dir, "a")}; !slices.Equal(got, want) {
+		t.Errorf("crumbs end with %v, want %v", got, want)
+	}
+	if root := paths[0]; filepath.Dir(root) != root {
+		t.Errorf("crumbs start with %q, want the root", root)
+	}
+
+	v.chdir(filepath.Join(dir, "missing"))
+	if v.err == nil || len(v.entries) != 0 {
+		t.Errorf("expected an error listing a missing folder")
+	}
+}
+
+func TestFilters(t *testing.T) {
+	dir := newTree(t)
+	opts := explorer.Options{
+		Folder: dir,
+		Filters: []explorer.Filter{
+			{Name: "Images", MIMETypes: []string{"image/png"}},
+			{Extensions: []string{"txt"}},
+		},
+		DefaultFilter: 1,
+	}
+	v := openView(request{kind: kindOpen, opts: opts})
+	if got, want := names(v), []string{"a", "b.txt"}; !slices.Equal(got, want) {
+		t.Errorf("default filter listed %v, want %v", got, want)
+	}
+
+	v.filter = 0
+	v.chdir(v.dir)
+	if got, want := names(v), []string{"a", "C.PNG", "d.png"}; !slices.Equal(got, want) {
+		t.Errorf("MIME type filter listed %v, want %v ignoring case", got, want)
+	}
+	if got, want := filterLabel(opts.Filters[1]), "*.txt"; got != want {
+		t.Errorf("unnamed filter is labelled %q, want %q", got, want)
+	}
+
+	v = openView(request{kind: kindFolder, opts: opts})
+	if got, want := names(v), []string{"a"}; !slices.Equal(got, want) {
+		t.Errorf("folder request listed %v, want only folders %v", got, want)
+	}
+	if got, want := v.selection(), []string{dir}; !slices.Equal(got, want) {
+		t.Errorf("folder request selects %v without a selected entry, want %v", got, want)
+	}
+}
+
+func TestNameEntry(t *testing.T) {
+	dir := newTree(t)
+	v := openView(request{kind: kindSave, opts: explorer.Options{Folder: dir, Name: "new.txt"}})
+	if got, want := v.selection(), []string{filepath.Join(dir, "new.txt")}; !slices.Equal(got, want) {
+		t.Errorf("suggested name selects %v, want %v", got, want)
+	}
+
+	v.name.SetText("  ")
+	if got := v.selection(); len(got) != 0 {
+		t.Errorf("blank name selects %v, want nothing", got)
+	}
+	abs := filepath.Join(t.TempDir(), "elsewhere.txt")
+	v.name.SetText(abs)
+	if got, want := v.selection(), []string{abs}; !slices.Equal(got, want) {
+		t.Errorf("absolute name selects %v, want %v", got, want)
+	}
+
+	v.selectEntry(index(t, v, "b.txt"), false, false)
+	if got := v.name.Text(); got != "b.txt" {
+		t.Errorf("selecting a file set the name %q, want b.txt", got)
+	}
+
+	// Submitting the name of a folder opens it.
+	v.name.SetText("a")
+	v.submit()
+	if want := filepath.Join(dir, "a"); v.dir != want || v.name.Text() != "" {
+		t.Errorf("submitting a folder name opened %q with name %q, want %q", v.dir, v.name.Text(), want)
+	}
+	v.name.SetText("out.txt")
+	req := v.req
+	v.submit()
+	if res := <-req.result; res.err != nil || !slices.Equal(res.paths, []string{filepath.Join(dir, "a", "out.txt")}) {
+		t.Errorf("submitting a name chose %v, %v", res.paths, res.err)
+	}
+	if v.req != nil {
+		t.Errorf("expected the request to be finished")
+	}
+}
+
+func TestMultipleSelection(t *testing.T) {
+	dir := newTree(t)
+	v := openView(request{kind: kindOpen, multi: true, opts: explorer.Options{Folder: dir}})
+	v.selectEntry(index(t, v, "b.txt"), false, false)
+	v.selectEntry(index(t, v, "d.png"), true, false)
+	want := []string{filepath.Join(dir, "b.txt"), filepath.Join(dir, "C.PNG"), filepath.Join(dir, "d.png")}
+	if got := v.selection(); !slices.Equal(got, want) {
+		t.Errorf("shift selection chose %v, want %v", got, want)
+	}
+	v.key(key.Event{Name: key.NameSpace, State: key.Press})
+	if got := v.selection(); !slices.Equal(got, want[:2]) {
+		t.Errorf("space toggled the selection to %v, want %v", got, want[:2])
+	}
+}
+
+// show calls Update until the browser shows a request.
+func show(t *testing.T, b *Browser) *view {
+	t.Helper()
+	gtx := layout.Context{Now: time.Now()}
+	for start := time.Now(); b.view.req == nil; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("no request was shown")
+		}
+		b.Update(gtx)
+	}
+	return &b.view
+}
+
+func TestBrowserDialogs(t *testing.T) {
+	dir := newTree(t)
+	b := New(nil, new(component.ModalLayer))
+
+	type chosen struct {
+		file io.ReadCloser
+		err  error
+	}
+	done := make(chan chosen, 1)
+	go func() {
+		f, err := b.ChooseFileContext(context.Background(), explorer.Options{Folder: dir})
+		done <- chosen{f, err}
+	}()
+	v := show(t, b)
+	v.activate(index(t, v, "d.png"))
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	defer res.file.Close()
+	f, ok := res.file.(*explorer.File)
+	if !ok {
+		t.Fatalf("chose a %T, want an *explorer.File", res.file)
+	}
+	if path := filepath.Join(dir, "d.png"); f.Name() != "d.png" || f.URI() != path || f.MIMEType() != "image/png" {
+		t.Errorf("chose %q at %q of type %q, want d.png at %q of type image/png", f.Name(), f.URI(), f.MIMEType(), path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.CreateFileContext(ctx, explorer.Options{Folder: dir})
+		errs <- err
+	}()
+	show(t, b)
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled dialog returned %v, want context.Canceled", err)
+	}
+	b.Update(layout.Context{Now: time.Now()})
+	if b.view.req != nil {
+		t.Errorf("expected Update to hide the cancelled dialog")
+	}
+}
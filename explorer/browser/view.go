@@ -0,0 +1,619 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package browser
+
+import (
+	"image"
+	"image/color"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"gioui.org/x/component"
+	"gioui.org/x/explorer"
+)
+
+// view is the widget of a Browser. It lists the entries of a folder, and is
+// operated by pointer or keyboard: the arrow keys move the cursor, extending
+// the selection with shift; space toggles the entry under the cursor; return
+// opens the entry; backspace opens the parent folder; and escape declines the
+// request.
+type view struct {
+	// req is the shown request, or nil once it's finished.
+	req *request
+	// dir is the path of the listed folder, and entries its entries.
+	dir     string
+	entries []entry
+	// err is the error listing dir.
+	err error
+	// filter is the index of the selected filter of the request.
+	filter int
+	// cursor is the index of the entry under the keyboard cursor, or -1, and
+	// anchor the first entry of a range selected with shift.
+	cursor, anchor int
+	// focus requests the keyboard focus for the next frame.
+	focus bool
+	// surface is the tag catching presses on the view, so that they don't
+	// dismiss the modal beneath.
+	surface bool
+
+	list    widget.List
+	path    layout.List
+	rows    []widget.Clickable
+	crumbs  []widget.Clickable
+	filters []widget.Clickable
+	name    widget.Editor
+	up      widget.Clickable
+	accept  widget.Clickable
+	cancel  widget.Clickable
+}
+
+// entry is a file or folder listed by the view.
+type entry struct {
+	name     string
+	dir      bool
+	selected bool
+}
+
+// open shows the view for req.
+func (b *view) open(req *request) {
+	b.req = req
+	b.filter = 0
+	if i := req.opts.DefaultFilter; i > 0 && i < len(req.opts.Filters) {
+		b.filter = i
+	}
+	b.name.SingleLine = true
+	b.name.Submit = true
+	b.name.SetText(req.opts.Name)
+	b.list.Axis = layout.Vertical
+	b.path.ScrollToEnd = true
+	b.focus = true
+	dir := req.opts.Folder
+	if dir == "" {
+		var err error
+		if dir, err = os.UserHomeDir(); err != nil {
+			dir = string(filepath.Separator)
+		}
+	}
+	b.chdir(dir)
+}
+
+// chdir lists the folder at path.
+func (b *view) chdir(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	b.dir = path
+	b.entries = b.entries[:0]
+	b.cursor, b.anchor = -1, 0
+	b.list.Position = layout.Position{}
+	files, err := os.ReadDir(path)
+	b.err = err
+	for _, f := range files {
+		name := f.Name()
+		if strings.HasPrefix(name, ".") {
+			// Hide hidden files.
+			continue
+		}
+		dir := f.IsDir()
+		if f.Type()&fs.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(path, name)); err == nil {
+				dir = info.IsDir()
+			}
+		}
+		if !dir && (b.req.kind == kindFolder || !b.matches(name)) {
+			continue
+		}
+		b.entries = append(b.entries, entry{name: name, dir: dir})
+	}
+	// List folders first.
+	slices.SortFunc(b.entries, func(a, b entry) int {
+		if a.dir != b.dir {
+			if a.dir {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(strings.ToLower(a.name), strings.ToLower(b.name))
+	})
+	if n := len(b.entries); len(b.rows) < n {
+		b.rows = append(b.rows, make([]widget.Clickable, n-len(b.rows))...)
+	}
+}
+
+// matches reports whether the file name is accepted by the selected filter.
+func (b *view) matches(name string) bool {
+	filters := b.req.opts.Filters
+	if len(filters) == 0 {
+		return true
+	}
+	exts := extensions(filters[b.filter])
+	if len(exts) == 0 {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, ext := range exts {
+		if strings.HasSuffix(name, strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// finish reports the result of the request, and hides the view.
+func (b *view) finish(paths []string, err error) {
+	b.req.result <- result{paths: paths, err: err}
+	b.req = nil
+}
+
+// selection returns the paths that accepting the request chooses.
+func (b *view) selection() []string {
+	switch b.req.kind {
+	case kindSave:
+		name := strings.TrimSpace(b.name.Text())
+		if name == "" {
+			return nil
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(b.dir, name)
+		}
+		return []string{name}
+	case kindFolder:
+		for _, e := range b.entries {
+			if e.selected {
+				return []string{filepath.Join(b.dir, e.name)}
+			}
+		}
+		return []string{b.dir}
+	}
+	var paths []string
+	for _, e := range b.entries {
+		if e.selected && !e.dir {
+			paths = append(paths, filepath.Join(b.dir, e.name))
+		}
+	}
+	return paths
+}
+
+// submit accepts the request with the selected paths, if any. A name typed
+// for a new file that refers to a folder opens the folder instead.
+func (b *view) submit() {
+	paths := b.selection()
+	if len(paths) == 0 {
+		return
+	}
+	if b.req.kind == kindSave {
+		if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
+			b.name.SetText("")
+			b.chdir(paths[0])
+			return
+		}
+	}
+	b.finish(paths, nil)
+}
+
+// activate opens the folder of entry i, or accepts its file.
+func (b *view) activate(i int) {
+	if e := b.entries[i]; e.dir {
+		b.chdir(filepath.Join(b.dir, e.name))
+		return
+	}
+	b.selectEntry(i, false, false)
+	b.submit()
+}
+
+// selectEntry moves the cursor to entry i and selects it. If extend is set,
+// the range from the anchor is selected instead, and if toggle is set, the
+// entry is added to or removed from the selection. Both are ignored unless
+// the request accepts multiple files.
+func (b *view) selectEntry(i int, extend, toggle bool) {
+	multi := b.req.multi
+	switch {
+	case multi && extend:
+		lo, hi := min(b.anchor, i), max(b.anchor, i)
+		for j := range b.entries {
+			b.entries[j].selected = j >= lo && j <= hi
+		}
+	case multi && toggle:
+		b.entries[i].selected = !b.entries[i].selected
+		b.anchor = i
+	default:
+		for j := range b.entries {
+			b.entries[j].selected = j == i
+		}
+		b.anchor = i
+	}
+	b.cursor = i
+	if e := b.entries[i]; b.req.kind == kindSave && !e.dir {
+		b.name.SetText(e.name)
+	}
+}
+
+// move moves the cursor to entry i, scrolling it into view.
+func (b *view) move(i int, extend bool) {
+	if len(b.entries) == 0 {
+		return
+	}
+	i = max(0, min(i, len(b.entries)-1))
+	b.selectEntry(i, extend, false)
+	pos := b.list.Position
+	if i < pos.First || i == pos.First && pos.Offset > 0 {
+		b.list.ScrollTo(i)
+	} else if last := pos.First + pos.Count - 1; pos.Count > 1 && i >= last {
+		// The last visible entry may be cut off.
+		b.list.ScrollBy(float32(i - last + 1))
+	}
+}
+
+// key handles a key press.
+func (b *view) key(e key.Event) {
+	extend := e.Modifiers.Contain(key.ModShift)
+	switch e.Name {
+	case key.NameEscape:
+		b.finish(nil, explorer.ErrUserDecline)
+	case key.NameDeleteBackward:
+		b.chdir(filepath.Dir(b.dir))
+	case key.NameReturn, key.NameEnter:
+		if b.cursor >= 0 && b.entries[b.cursor].dir {
+			b.activate(b.cursor)
+		} else {
+			b.submit()
+		}
+	case key.NameSpace:
+		if b.cursor >= 0 {
+			b.selectEntry(b.cursor, false, true)
+		}
+	case key.NameUpArrow:
+		b.move(b.cursor-1, extend)
+	case key.NameDownArrow:
+		b.move(b.cursor+1, extend)
+	case key.NameHome:
+		b.move(0, extend)
+	case key.NameEnd:
+		b.move(len(b.entries)-1, extend)
+	}
+}
+
+// update processes the events of the view.
+func (b *view) update(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(
+			key.FocusFilter{Target: b},
+			key.Filter{Focus: b, Name: key.NameUpArrow, Optional: key.ModShift},
+			key.Filter{Focus: b, Name: key.NameDownArrow, Optional: key.ModShift},
+			key.Filter{Focus: b, Name: key.NameHome, Optional: key.ModShift},
+			key.Filter{Focus: b, Name: key.NameEnd, Optional: key.ModShift},
+			key.Filter{Focus: b, Name: key.NameReturn},
+			key.Filter{Focus: b, Name: key.NameEnter},
+			key.Filter{Focus: b, Name: key.NameSpace},
+			key.Filter{Focus: b, Name: key.NameDeleteBackward},
+			key.Filter{Name: key.NameEscape},
+			pointer.Filter{Target: b, Kinds: pointer.Press},
+		)
+		if !ok {
+			break
+		}
+		switch ev := ev.(type) {
+		case key.Event:
+			if ev.State == key.Press {
+				b.key(ev)
+			}
+		case pointer.Event:
+			gtx.Execute(key.FocusCmd{Tag: b})
+		}
+		if b.req == nil {
+			return
+		}
+	}
+	for {
+		if _, ok := gtx.Event(pointer.Filter{Target: &b.surface, Kinds: pointer.Press}); !ok {
+			break
+		}
+	}
+	if b.cancel.Clicked(gtx) {
+		b.finish(nil, explorer.ErrUserDecline)
+		return
+	}
+	if b.accept.Clicked(gtx) {
+		b.submit()
+	}
+	for {
+		ev, ok := b.name.Update(gtx)
+		if !ok || b.req == nil {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			b.submit()
+		}
+	}
+	if b.req == nil {
+		return
+	}
+	if b.up.Clicked(gtx) {
+		b.chdir(filepath.Dir(b.dir))
+	}
+	for i, path := range crumbs(b.dir) {
+		if i < len(b.crumbs) && b.crumbs[i].Clicked(gtx) {
+			b.chdir(path)
+			break
+		}
+	}
+	for i := range b.req.opts.Filters {
+		if i < len(b.filters) && b.filters[i].Clicked(gtx) && i != b.filter {
+			b.filter = i
+			b.chdir(b.dir)
+		}
+	}
+	for i := range b.entries {
+		click, ok := b.rows[i].Update(gtx)
+		if !ok {
+			continue
+		}
+		gtx.Execute(key.FocusCmd{Tag: b})
+		if click.NumClicks > 1 {
+			b.activate(i)
+			break
+		}
+		b.selectEntry(i, click.Modifiers.Contain(key.ModShift), click.Modifiers.Contain(key.ModShortcut))
+	}
+	if b.focus {
+		b.focus = false
+		var tag event.Tag = b
+		if b.req != nil && b.req.kind == kindSave {
+			tag = &b.name
+		}
+		gtx.Execute(key.FocusCmd{Tag: tag})
+	}
+}
+
+// Layout draws the view centered in the constraints.
+func (b *view) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	b.update(gtx)
+	if b.req == nil {
+		return layout.Dimensions{}
+	}
+	return layout.UniformInset(unit.Dp(24)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(640))
+			gtx.Constraints.Max.Y = min(gtx.Constraints.Max.Y, gtx.Dp(480))
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return component.Surface(th).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+				event.Op(gtx.Ops, &b.surface)
+				return layout.UniformInset(unit.Dp(16)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return b.layoutContents(gtx, th)
+				})
+			})
+		})
+	})
+}
+
+func (b *view) layoutContents(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(material.H6(th, b.title()).Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return b.layoutPath(gtx, th)
+			})
+		}),
+	}
+	if len(b.req.opts.Filters) > 1 {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return b.layoutFilters(gtx, th)
+		}))
+	}
+	children = append(children, layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return b.layoutEntries(gtx, th)
+		})
+	}))
+	if b.req.kind == kindSave {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Border{
+					Color:        component.WithAlpha(th.Fg, 0x60),
+					CornerRadius: unit.Dp(4),
+					Width:        unit.Dp(1),
+				}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min.X = gtx.Constraints.Max.X
+					return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Editor(th, &b.name, "Name").Layout)
+				})
+			})
+		}))
+	}
+	children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Top: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return b.layoutButtons(gtx, th)
+		})
+	}))
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// layoutPath draws the up button and the breadcrumbs of the listed folder.
+func (b *view) layoutPath(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	paths := crumbs(b.dir)
+	if n := len(paths); len(b.crumbs) < n {
+		b.crumbs = append(b.crumbs, make([]widget.Clickable, n-len(b.crumbs))...)
+	}
+	return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+		layout.Rigid(flatButton(th, &b.up, "Up").Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return b.path.Layout(gtx, len(paths), func(gtx layout.Context, i int) layout.Dimensions {
+				name := filepath.Base(paths[i])
+				if i == 0 {
+					name = paths[i]
+				}
+				crumb := flatButton(th, &b.crumbs[i], name)
+				if i == len(paths)-1 {
+					crumb.Color = th.Fg
+				}
+				if i == 0 {
+					return crumb.Layout(gtx)
+				}
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(material.Body1(th, "›").Layout),
+					layout.Rigid(crumb.Layout),
+				)
+			})
+		}),
+	)
+}
+
+// layoutFilters draws the filters of the request, highlighting the selected
+// one.
+func (b *view) layoutFilters(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	filters := b.req.opts.Filters
+	if n := len(filters); len(b.filters) < n {
+		b.filters = append(b.filters, make([]widget.Clickable, n-len(b.filters))...)
+	}
+	children := make([]layout.FlexChild, len(filters))
+	for i, f := range filters {
+		children[i] = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			label := filterLabel(f)
+			if i == b.filter {
+				return material.Button(th, &b.filters[i], label).Layout(gtx)
+			}
+			return flatButton(th, &b.filters[i], label).Layout(gtx)
+		})
+	}
+	return layout.Flex{}.Layout(gtx, children...)
+}
+
+// layoutEntries draws the entries of the listed folder, or the error listing
+// it.
+func (b *view) layoutEntries(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	size := gtx.Constraints.Max
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, b)
+	if b.err != nil {
+		gtx.Constraints.Min = image.Point{}
+		material.Body1(th, b.err.Error()).Layout(gtx)
+		return layout.Dimensions{Size: size}
+	}
+	focused := gtx.Focused(b)
+	material.List(th, &b.list).Layout(gtx, len(b.entries), func(gtx layout.Context, i int) layout.Dimensions {
+		e := b.entries[i]
+		return b.rows[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Background{}.Layout(gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					var fill color.NRGBA
+					switch {
+					case e.selected:
+						fill = component.WithAlpha(th.ContrastBg, 0x60)
+					case focused && i == b.cursor:
+						fill = component.WithAlpha(th.Fg, 0x20)
+					case b.rows[i].Hovered():
+						fill = component.WithAlpha(th.Fg, 0x10)
+					}
+					paint.FillShape(gtx.Ops, fill, clip.Rect{Max: gtx.Constraints.Min}.Op())
+					return layout.Dimensions{Size: gtx.Constraints.Min}
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					name := e.name
+					if e.dir {
+						name += string(filepath.Separator)
+					}
+					return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Body1(th, name).Layout)
+				},
+			)
+		})
+	})
+	return layout.Dimensions{Size: size}
+}
+
+// layoutButtons draws the buttons that decline and accept the request.
+func (b *view) layoutButtons(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return layout.Flex{Spacing: layout.SpaceStart}.Layout(gtx,
+		layout.Rigid(flatButton(th, &b.cancel, "Cancel").Layout),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if len(b.selection()) == 0 {
+				gtx = gtx.Disabled()
+			}
+			return material.Button(th, &b.accept, b.acceptLabel()).Layout(gtx)
+		}),
+	)
+}
+
+func (b *view) title() string {
+	if t := b.req.opts.Title; t != "" {
+		return t
+	}
+	switch {
+	case b.req.kind == kindSave:
+		return "Choose Save Location"
+	case b.req.kind == kindFolder:
+		return "Choose Folder"
+	case b.req.multi:
+		return "Choose Files"
+	}
+	return "Choose File"
+}
+
+func (b *view) acceptLabel() string {
+	if l := b.req.opts.AcceptLabel; l != "" {
+		return l
+	}
+	switch b.req.kind {
+	case kindSave:
+		return "Save"
+	case kindFolder:
+		return "Choose"
+	}
+	return "Open"
+}
+
+// crumbs returns the paths of dir and the folders containing it, outermost
+// first.
+func crumbs(dir string) []string {
+	var paths []string
+	for {
+		paths = append(paths, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	slices.Reverse(paths)
+	return paths
+}
+
+// filterLabel returns the name of f, or its file types if it has none.
+func filterLabel(f explorer.Filter) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	patterns := extensions(f)
+	for i, ext := range patterns {
+		patterns[i] = "*" + ext
+	}
+	return strings.Join(patterns, ", ")
+}
+
+// extensions returns the extensions accepted by f, with a leading dot,
+// including the extensions known for its MIME types.
+func extensions(f explorer.Filter) []string {
+	return explorer.Options{Filters: []explorer.Filter{f}}.Extensions()
+}
+
+// flatButton returns a button without background.
+func flatButton(th *material.Theme, c *widget.Clickable, label string) material.ButtonStyle {
+	btn := material.Button(th, c, label)
+	btn.Background = color.NRGBA{}
+	btn.Color = th.ContrastBg
+	btn.Inset = layout.UniformInset(unit.Dp(8))
+	return btn
+}
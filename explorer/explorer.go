@@ -3,6 +3,7 @@
 package explorer
 
 import (
+	"context"
 	"errors"
 	"io"
 	"runtime"
@@ -17,8 +18,14 @@ var (
 	// ErrUserDecline is returned when the user doesn't select the file.
 	ErrUserDecline = errors.New("user exited the file selector without selecting a file")
 
-	// ErrNotAvailable is return when the current OS isn't supported.
+	// ErrNotAvailable is return when the current OS isn't supported, or its
+	// dialogs are unavailable.
 	ErrNotAvailable = errors.New("current OS not supported")
+
+	// ErrNotAtomic is returned when a file created with Options.Atomic can't
+	// be written atomically, because no temporary file can be created next
+	// to it.
+	ErrNotAtomic = errors.New("file can't be written atomically")
 )
 
 type result struct {
@@ -28,13 +35,35 @@ type result struct {
 
 // Explorer facilitates opening OS-native dialogs to choose files and create files.
 type Explorer struct {
-	id    int32
-	mutex sync.Mutex
+	id int32
+	// lock is held while a dialog is shown. It's a channel rather than a
+	// sync.Mutex so that waiting for it can be cancelled.
+	lock chan struct{}
+	// window is invalidated when the result of an asynchronous dialog is
+	// ready.
+	window *app.Window
+	// async holds the state of asynchronous dialogs.
+	async async
+	// fallback holds the dialogs configured by SetFallback.
+	fallback fallback
 
 	// explorer holds OS-Specific content, it varies for each OS.
 	*explorer
 }
 
+// Dialogs shows file dialogs. It's implemented by *Explorer, by the in-app
+// file browser of package browser, and by the fake of package explorertest,
+// which lets code showing dialogs be tested.
+type Dialogs interface {
+	ChooseFileContext(ctx context.Context, opts Options) (io.ReadCloser, error)
+	ChooseFilesContext(ctx context.Context, opts Options) ([]io.ReadCloser, error)
+	ChooseFolderContext(ctx context.Context, opts Options) (string, error)
+	CreateFileContext(ctx context.Context, opts Options) (io.WriteCloser, error)
+	ReadFile(uri string) (io.ReadCloser, error)
+}
+
+var _ Dialogs = (*Explorer)(nil)
+
 // active holds all explorer currently active, that may necessary for callback functions.
 //
 // Some OSes (Android, iOS, macOS) may call Golang exported functions as callback, but we need
@@ -59,6 +88,8 @@ func NewExplorer(w *app.Window) (e *Explorer) {
 	e = &Explorer{
 		explorer: newExplorer(w),
 		id:       atomic.AddInt32(counter, 1),
+		lock:     make(chan struct{}, 1),
+		window:   w,
 	}
 
 	active.Store(e.id, e.explorer)
@@ -94,24 +125,34 @@ func (e *Explorer) ListenEvents(evt event.Event) {
 // Example: ChooseFile(".jpg", ".png") will only accept the selection of files with
 // .jpg or .png extensions.
 //
-// In some platforms the resulting `io.ReadCloser` is a `os.File`, but it's not
-// a guarantee.
+// The resulting `io.ReadCloser` is a `*File`, which describes the file.
 //
 // In most known browsers, when user clicks cancel then this function never returns.
+// ChooseFileContext can be used to stop waiting.
 //
 // It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
 // ChooseFile or CreateFile, can happen at the same time, for each app.Window/Explorer.
 func (e *Explorer) ChooseFile(extensions ...string) (io.ReadCloser, error) {
-	if e == nil {
-		return nil, ErrNotAvailable
-	}
+	return e.ChooseFileWithOptions(extensionOptions(extensions))
+}
 
-	if runtime.GOOS != "js" {
-		e.mutex.Lock()
-		defer e.mutex.Unlock()
-	}
+// ChooseFileWithOptions is like ChooseFile, but configures the file selector
+// with opts.
+func (e *Explorer) ChooseFileWithOptions(opts Options) (io.ReadCloser, error) {
+	return e.ChooseFileContext(context.Background(), opts)
+}
 
-	return e.importFile(extensions...)
+// ChooseFileContext is like ChooseFileWithOptions, but returns ctx.Err() once ctx
+// is done. The file selector is closed where the platform allows it; otherwise
+// a file chosen afterwards is closed and discarded.
+func (e *Explorer) ChooseFileContext(ctx context.Context, opts Options) (io.ReadCloser, error) {
+	return do(ctx, e, func(ctx context.Context) (io.ReadCloser, error) {
+		return e.importFile(ctx, opts)
+	}, func(ctx context.Context, d Dialogs) (io.ReadCloser, error) {
+		return d.ChooseFileContext(ctx, opts)
+	}, func(file io.ReadCloser) {
+		file.Close()
+	})
 }
 
 // ReadFile opens and returns a reader for the file at the given URI.
@@ -150,14 +191,27 @@ func (e *Explorer) ReadFile(uri string) (r io.ReadCloser, err error) {
 		return nil, ErrNotAvailable
 	}
 
-	if runtime.GOOS != "js" {
-		e.mutex.Lock()
-		defer e.mutex.Unlock()
-	}
+	e.acquire(context.Background())
+	defer e.release()
 
 	return e.readFile(uri)
 }
 
+// OpenPath opens the file at path for reading, like the files chosen through
+// dialogs. It's meant for implementations of Dialogs that choose files by
+// their path, such as the in-app file browser of package browser. It returns
+// ErrNotAvailable where files can't be opened by their path, such as on
+// Android.
+func OpenPath(path string) (*File, error) {
+	return openFile(path)
+}
+
+// CreatePath creates or truncates the file at path like the files created
+// through dialogs, honouring opts.Atomic. See OpenPath.
+func CreatePath(path string, opts Options) (*File, error) {
+	return createFileOptions(path, opts)
+}
+
 // ChooseFiles shows the files selector, allowing the user to select multiple files.
 // Optionally, it's possible to define which file extensions is supported to
 // be selected (such as `.jpg`, `.png`).
@@ -165,24 +219,67 @@ func (e *Explorer) ReadFile(uri string) (r io.ReadCloser, err error) {
 // Example: ChooseFiles(".jpg", ".png") will only accept the selection of files with
 // .jpg or .png extensions.
 //
-// In some platforms the resulting `io.ReadCloser` is a `os.File`, but it's not
-// a guarantee.
+// The resulting `io.ReadCloser` is a `*File`, which describes the file.
 //
 // In most known browsers, when user clicks cancel then this function never returns.
+// ChooseFilesContext can be used to stop waiting.
 //
 // It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
 // ChooseFile{,s} or CreateFile, can happen at the same time, for each app.Window/Explorer.
 func (e *Explorer) ChooseFiles(extensions ...string) ([]io.ReadCloser, error) {
-	if e == nil {
-		return nil, ErrNotAvailable
-	}
+	return e.ChooseFilesWithOptions(extensionOptions(extensions))
+}
 
-	if runtime.GOOS != "js" {
-		e.mutex.Lock()
-		defer e.mutex.Unlock()
-	}
+// ChooseFilesWithOptions is like ChooseFiles, but configures the files selector
+// with opts.
+func (e *Explorer) ChooseFilesWithOptions(opts Options) ([]io.ReadCloser, error) {
+	return e.ChooseFilesContext(context.Background(), opts)
+}
+
+// ChooseFilesContext is like ChooseFilesWithOptions, but returns ctx.Err() once
+// ctx is done. The files selector is closed where the platform allows it;
+// otherwise files chosen afterwards are closed and discarded.
+func (e *Explorer) ChooseFilesContext(ctx context.Context, opts Options) ([]io.ReadCloser, error) {
+	return do(ctx, e, func(ctx context.Context) ([]io.ReadCloser, error) {
+		return e.importFiles(ctx, opts)
+	}, func(ctx context.Context, d Dialogs) ([]io.ReadCloser, error) {
+		return d.ChooseFilesContext(ctx, opts)
+	}, func(files []io.ReadCloser) {
+		for _, file := range files {
+			file.Close()
+		}
+	})
+}
+
+// ChooseFolder shows the folder selector, allowing the user to select a single
+// folder. It returns the path of the chosen folder.
+//
+// It returns ErrNotAvailable on platforms without a folder selector, which
+// currently includes Android, iOS and browsers.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// dialog can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) ChooseFolder() (string, error) {
+	return e.ChooseFolderWithOptions(Options{})
+}
+
+// ChooseFolderWithOptions is like ChooseFolder, but configures the folder
+// selector with opts. Filters, Name and Atomic don't apply to folders, and
+// are ignored.
+func (e *Explorer) ChooseFolderWithOptions(opts Options) (string, error) {
+	return e.ChooseFolderContext(context.Background(), opts)
+}
 
-	return e.importFiles(extensions...)
+// ChooseFolderContext is like ChooseFolderWithOptions, but returns ctx.Err()
+// once ctx is done. The folder selector is closed where the platform allows
+// it.
+func (e *Explorer) ChooseFolderContext(ctx context.Context, opts Options) (string, error) {
+	opts.Filters, opts.DefaultFilter, opts.Name, opts.Atomic = nil, 0, "", false
+	return do(ctx, e, func(ctx context.Context) (string, error) {
+		return e.chooseFolder(ctx, opts)
+	}, func(ctx context.Context, d Dialogs) (string, error) {
+		return d.ChooseFolderContext(ctx, opts)
+	}, nil)
 }
 
 // CreateFile opens the file selector, and writes the given content into
@@ -191,22 +288,128 @@ func (e *Explorer) ChooseFiles(extensions ...string) ([]io.ReadCloser, error) {
 // It's important to close the `io.WriteCloser`. In some platforms the
 // file will be saved only when the writer is closer.
 //
-// In some platforms the resulting `io.WriteCloser` is a `os.File`, but it's not
-// a guarantee.
+// The resulting `io.WriteCloser` is a `*File`, which describes the file. It
+// implements io.WriteSeeker, for formats that patch earlier data, but Seek
+// returns ErrNotAvailable where the platform writes files as streams, such as
+// in browsers. Options.Atomic avoids partially written files.
 //
 // It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
 // ChooseFile or CreateFile, can happen at the same time, for each app.Window/Explorer.
 func (e *Explorer) CreateFile(name string) (io.WriteCloser, error) {
+	return e.CreateFileWithOptions(Options{Name: name})
+}
+
+// CreateFileWithOptions is like CreateFile, but configures the file selector
+// with opts. The suggested name of the file is opts.Name.
+func (e *Explorer) CreateFileWithOptions(opts Options) (io.WriteCloser, error) {
+	return e.CreateFileContext(context.Background(), opts)
+}
+
+// CreateFileContext is like CreateFileWithOptions, but returns ctx.Err() once
+// ctx is done. The file selector is closed where the platform allows it;
+// otherwise a file created afterwards is closed and discarded.
+func (e *Explorer) CreateFileContext(ctx context.Context, opts Options) (io.WriteCloser, error) {
+	return do(ctx, e, func(ctx context.Context) (io.WriteCloser, error) {
+		return e.exportFile(ctx, opts)
+	}, func(ctx context.Context, d Dialogs) (io.WriteCloser, error) {
+		return d.CreateFileContext(ctx, opts)
+	}, func(file io.WriteCloser) {
+		file.Close()
+	})
+}
+
+// OpenURI opens uri, such as a web address or the URI of a file returned by
+// File.URI, with the application that the system associates with it. Paths
+// are opened as files.
+//
+// It returns ErrNotAvailable where the system can't open uri, which includes
+// paths on Android and browsers. It may block until the system opened uri.
+func (e *Explorer) OpenURI(uri string) error {
 	if e == nil {
-		return nil, ErrNotAvailable
+		return ErrNotAvailable
 	}
+	return e.openURI(uri)
+}
 
-	if runtime.GOOS != "js" {
-		e.mutex.Lock()
-		defer e.mutex.Unlock()
+// RevealFile shows the file at path in the file manager of the system,
+// selecting it where possible.
+//
+// It returns ErrNotAvailable on platforms without a file manager, which
+// currently includes Android, iOS and browsers. It may block until the file
+// manager is shown.
+func (e *Explorer) RevealFile(path string) error {
+	if e == nil {
+		return ErrNotAvailable
 	}
+	return e.revealFile(path)
+}
 
-	return e.exportFile(name)
+// do runs work, which shows a dialog, or fallback, which shows a dialog of the
+// Dialogs configured by SetFallback, while holding the lock of e. If ctx is done
+// first, do returns ctx.Err() without waiting for the dialog. The lock is
+// released once the dialog returns, and its results are passed to discard, if
+// not nil.
+//
+// The dialogs of Linux, Windows and macOS close when ctx is done. The dialogs
+// of Android and iOS stay open until the user closes them, and later calls
+// wait for them, unless their own ctx is done first.
+func do[T any](ctx context.Context, e *Explorer, work func(ctx context.Context) (T, error), fallback func(ctx context.Context, d Dialogs) (T, error), discard func(T)) (T, error) {
+	var zero T
+	if e == nil {
+		return zero, ErrNotAvailable
+	}
+	if err := e.acquire(ctx); err != nil {
+		return zero, err
+	}
+	if ctx.Done() == nil {
+		// The call can't be cancelled.
+		defer e.release()
+		return withFallback(ctx, e, work, fallback)
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer e.release()
+		v, err := withFallback(ctx, e, work, fallback)
+		done <- result{value: v, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-done; res.err == nil && discard != nil {
+				discard(res.value)
+			}
+		}()
+		return zero, ctx.Err()
+	}
+}
+
+// acquire waits for the lock of e, which serializes dialogs, or for ctx to be
+// done. Browsers don't need the lock.
+func (e *Explorer) acquire(ctx context.Context) error {
+	if runtime.GOOS == "js" {
+		return nil
+	}
+	select {
+	case e.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release releases the lock acquired by acquire.
+func (e *Explorer) release() {
+	if runtime.GOOS == "js" {
+		return
+	}
+	<-e.lock
 }
 
 var (
@@ -10,9 +10,9 @@ package explorer
 */
 import "C"
 import (
+	"context"
 	"errors"
 	"io"
-	"mime"
 	"path/filepath"
 	"strings"
 	"unsafe"
@@ -82,7 +82,7 @@ func (e *Explorer) listenEvents(evt event.Event) {
 	}
 }
 
-func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
+func (e *Explorer) exportFile(_ context.Context, opts Options) (io.WriteCloser, error) {
 	go e.window.Run(func() {
 		err := jni.Do(jni.JVMFor(app.JavaVM()), func(env jni.Env) error {
 			if err := e.init(env); err != nil {
@@ -91,7 +91,7 @@ func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
 
 			return jni.CallVoidMethod(env, e.libObject, e.explorer.exportFile,
 				jni.Value(e.view),
-				jni.Value(jni.JavaString(env, filepath.Base(name))),
+				jni.Value(jni.JavaString(env, filepath.Base(opts.Name))),
 				jni.Value(e.id),
 			)
 		})
@@ -108,12 +108,8 @@ func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
 	return file.file.(io.WriteCloser), nil
 }
 
-func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
-	for i, ext := range extensions {
-		extensions[i] = mime.TypeByExtension(ext)
-	}
-
-	mimes := strings.Join(extensions, ",")
+func (e *Explorer) importFile(_ context.Context, opts Options) (io.ReadCloser, error) {
+	mimes := strings.Join(opts.mimeTypes(), ",")
 	go e.window.Run(func() {
 		err := jni.Do(jni.JVMFor(app.JavaVM()), func(env jni.Env) error {
 			if err := e.init(env); err != nil {
@@ -173,10 +169,102 @@ func (e *Explorer) readFile(uri string) (io.ReadCloser, error) {
 	return file.file.(io.ReadCloser), nil
 }
 
-func (e *Explorer) importFiles(_ ...string) ([]io.ReadCloser, error) {
+func (e *Explorer) importFiles(_ context.Context, _ Options) ([]io.ReadCloser, error) {
 	return nil, ErrNotAvailable
 }
 
+func (e *Explorer) chooseFolder(_ context.Context, _ Options) (string, error) {
+	return "", ErrNotAvailable
+}
+
+// Flags of android.content.Intent.
+const (
+	_FLAG_GRANT_READ_URI_PERMISSION  = 0x00000001
+	_FLAG_GRANT_WRITE_URI_PERMISSION = 0x00000002
+	_FLAG_ACTIVITY_NEW_TASK          = 0x10000000
+)
+
+// parseURI returns the android.net.Uri of uri.
+func parseURI(env jni.Env, loader jni.Object, uri string) (jni.Object, error) {
+	uriClass, err := jni.LoadClass(env, loader, "android/net/Uri")
+	if err != nil {
+		return 0, err
+	}
+	parse := jni.GetStaticMethodID(env, uriClass, "parse", "(Ljava/lang/String;)Landroid/net/Uri;")
+	return jni.CallStaticObjectMethod(env, uriClass, parse, jni.Value(jni.JavaString(env, uri)))
+}
+
+// openURI starts the activity viewing uri. Content URIs are readable by the
+// activity.
+func (e *Explorer) openURI(uri string) error {
+	if _, ok := localPath(uri); ok {
+		return ErrNotAvailable
+	}
+	return jni.Do(jni.JVMFor(app.JavaVM()), func(env jni.Env) error {
+		appCtx := jni.Object(app.AppContext())
+		loader := jni.ClassLoaderFor(env, appCtx)
+		parsed, err := parseURI(env, loader, uri)
+		if err != nil {
+			return err
+		}
+		intentClass, err := jni.LoadClass(env, loader, "android/content/Intent")
+		if err != nil {
+			return err
+		}
+		intent, err := jni.NewObject(env, intentClass,
+			jni.GetMethodID(env, intentClass, "<init>", "(Ljava/lang/String;Landroid/net/Uri;)V"),
+			jni.Value(jni.JavaString(env, "android.intent.action.VIEW")),
+			jni.Value(parsed),
+		)
+		if err != nil {
+			return err
+		}
+		// Activities started from the application context need a new task.
+		addFlags := jni.GetMethodID(env, intentClass, "addFlags", "(I)Landroid/content/Intent;")
+		if _, err := jni.CallObjectMethod(env, intent, addFlags, jni.Value(_FLAG_ACTIVITY_NEW_TASK|_FLAG_GRANT_READ_URI_PERMISSION)); err != nil {
+			return err
+		}
+		startActivity := jni.GetMethodID(env, jni.GetObjectClass(env, appCtx), "startActivity", "(Landroid/content/Intent;)V")
+		return jni.CallVoidMethod(env, appCtx, startActivity, jni.Value(intent))
+	})
+}
+
+func (e *Explorer) revealFile(_ string) error {
+	return ErrNotAvailable
+}
+
+// keepAccess persists the permissions granted to the content URI, which
+// otherwise last until the app is stopped.
+func (e *Explorer) keepAccess(uri string) (string, error) {
+	if !strings.HasPrefix(uri, "content:") {
+		return uri, nil
+	}
+	err := jni.Do(jni.JVMFor(app.JavaVM()), func(env jni.Env) error {
+		appCtx := jni.Object(app.AppContext())
+		parsed, err := parseURI(env, jni.ClassLoaderFor(env, appCtx), uri)
+		if err != nil {
+			return err
+		}
+		getContentResolver := jni.GetMethodID(env, jni.GetObjectClass(env, appCtx), "getContentResolver", "()Landroid/content/ContentResolver;")
+		resolver, err := jni.CallObjectMethod(env, appCtx, getContentResolver)
+		if err != nil {
+			return err
+		}
+		take := jni.GetMethodID(env, jni.GetObjectClass(env, resolver), "takePersistableUriPermission", "(Landroid/net/Uri;I)V")
+		// Created files are writable, and chosen files are only readable,
+		// which fails to persist the write permission.
+		err = jni.CallVoidMethod(env, resolver, take, jni.Value(parsed), jni.Value(_FLAG_GRANT_READ_URI_PERMISSION|_FLAG_GRANT_WRITE_URI_PERMISSION))
+		if err != nil {
+			err = jni.CallVoidMethod(env, resolver, take, jni.Value(parsed), jni.Value(_FLAG_GRANT_READ_URI_PERMISSION))
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return uri, nil
+}
+
 //export Java_org_gioui_x_explorer_explorer_1android_ImportCallback
 func Java_org_gioui_x_explorer_explorer_1android_ImportCallback(env *C.JNIEnv, _ C.jclass, stream C.jobject, id C.jint, fileInfo C.jobject, err C.jstring) {
 	fileCallback(env, stream, id, fileInfo, err)
@@ -25,11 +25,11 @@ static const uint64_t EXPORT_MODE = 2;
 extern CFTypeRef createPicker(CFTypeRef controllerRef, int32_t id);
 extern bool exportFile(CFTypeRef expl, char * name);
 extern bool importFile(CFTypeRef expl, char * ext);
-extern CFTypeRef createURLFromPath(const char* path);
-extern void releaseURL(CFTypeRef url);
+extern bool openURL(char * uri);
 */
 import "C"
 import (
+	"context"
 	"io"
 	"os"
 	"path/filepath"
@@ -57,8 +57,8 @@ func (e *Explorer) listenEvents(evt event.Event) {
 	}
 }
 
-func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
-	name = filepath.Join(os.TempDir(), name)
+func (e *Explorer) exportFile(_ context.Context, opts Options) (io.WriteCloser, error) {
+	name := filepath.Join(os.TempDir(), opts.Name)
 
 	f, err := os.Create(name)
 	if err != nil {
@@ -81,7 +81,8 @@ func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
 	return file.file.(io.WriteCloser), nil
 }
 
-func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
+func (e *Explorer) importFile(_ context.Context, opts Options) (io.ReadCloser, error) {
+	extensions := opts.Extensions()
 	for i, ext := range extensions {
 		extensions[i] = strings.TrimPrefix(ext, ".")
 	}
@@ -101,20 +102,41 @@ func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
 }
 
 func (e *Explorer) readFile(url string) (io.ReadCloser, error) {
-	cPath := C.CString(url)
-	defer C.free(unsafe.Pointer(cPath))
-	nsUrl := C.createURLFromPath(cPath)
-	file, err := newFile(nsUrl)
-	if err != nil {
-		return nil, err
-	}
-	return file, nil
+	return openFile(url)
 }
 
-func (e *Explorer) importFiles(_ ...string) ([]io.ReadCloser, error) {
+func (e *Explorer) importFiles(_ context.Context, _ Options) ([]io.ReadCloser, error) {
 	return nil, ErrNotAvailable
 }
 
+func (e *Explorer) chooseFolder(_ context.Context, _ Options) (string, error) {
+	return "", ErrNotAvailable
+}
+
+func (e *Explorer) openURI(uri string) error {
+	if path, ok := localPath(uri); ok {
+		uri = fileURI(path)
+	}
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+	var ok bool
+	e.window.Run(func() { ok = bool(C.openURL(curi)) })
+	if !ok {
+		return ErrNotAvailable
+	}
+	return nil
+}
+
+func (e *Explorer) revealFile(_ string) error {
+	return ErrNotAvailable
+}
+
+// keepAccess does nothing. The security-scoped URLs of chosen files may not
+// be accessible after restarts, which RecentFiles.Accessible reports.
+func (e *Explorer) keepAccess(url string) (string, error) {
+	return url, nil
+}
+
 //export importCallback
 func importCallback(u C.CFTypeRef, id C.int32_t) {
 	fileCallback(u, id)
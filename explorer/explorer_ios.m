@@ -109,3 +109,12 @@ void releaseURL(CFTypeRef url) {
         CFRelease(url);
     }
 }
+
+bool openURL(char * uri) {
+    NSURL *url = [NSURL URLWithString:@(uri)];
+    if (url == nil || ![[UIApplication sharedApplication] canOpenURL:url]) {
+        return NO;
+    }
+    [[UIApplication sharedApplication] openURL:url options:@{} completionHandler:nil];
+    return YES;
+}
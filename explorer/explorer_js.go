@@ -3,9 +3,12 @@
 package explorer
 
 import (
+	"context"
+	"errors"
 	"io"
 	"strings"
 	"syscall/js"
+	"time"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
@@ -21,11 +24,15 @@ func (e *Explorer) listenEvents(_ event.Event) {
 	// NO-OP
 }
 
-func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
-	return newFileWriter(name), nil
+func (e *Explorer) exportFile(_ context.Context, opts Options) (io.WriteCloser, error) {
+	return &File{
+		file:     newFileWriter(opts.Name),
+		name:     opts.Name,
+		mimeType: mimeType(opts.Name),
+	}, nil
 }
 
-func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
+func (e *Explorer) importFile(_ context.Context, opts Options) (io.ReadCloser, error) {
 	// TODO: Replace with "File System Access API" when that becomes available on most browsers.
 	// BUG: Not work on iOS/Safari.
 
@@ -38,8 +45,9 @@ func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
 	input.Call("addEventListener", "cancel", openCallback(r))
 	input.Set("type", "file")
 	input.Set("style", "display:none;")
-	if len(extensions) > 0 {
-		input.Set("accept", strings.Join(extensions, ","))
+	// The accept attribute takes both extensions and MIME types.
+	if accept := append(opts.Extensions(), opts.mimeTypes()...); len(accept) > 0 {
+		input.Set("accept", strings.Join(accept, ","))
 	}
 	document.Get("body").Call("appendChild", input)
 	input.Call("click")
@@ -53,10 +61,29 @@ func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
 
 func (e *Explorer) readFile(_ string) (io.ReadCloser, error) { return nil, ErrNotAvailable }
 
-func (e *Explorer) importFiles(_ ...string) ([]io.ReadCloser, error) {
+func (e *Explorer) importFiles(_ context.Context, _ Options) ([]io.ReadCloser, error) {
 	return nil, ErrNotAvailable
 }
 
+func (e *Explorer) chooseFolder(_ context.Context, _ Options) (string, error) {
+	return "", ErrNotAvailable
+}
+
+func (e *Explorer) openURI(uri string) error {
+	if _, ok := localPath(uri); ok {
+		return ErrNotAvailable
+	}
+	// The window is null if the browser blocked it.
+	if w := js.Global().Call("open", uri, "_blank"); w.IsNull() {
+		return ErrNotAvailable
+	}
+	return nil
+}
+
+func (e *Explorer) revealFile(_ string) error { return ErrNotAvailable }
+
+func (e *Explorer) keepAccess(_ string) (string, error) { return "", ErrNotAvailable }
+
 type FileReader struct {
 	buffer                   js.Value
 	isClosed                 bool
@@ -105,6 +132,22 @@ func (f *FileReader) Read(b []byte) (n int, err error) {
 	return n, err
 }
 
+// Seek implements io.Seeker.
+func (f *FileReader) Seek(offset int64, whence int) (int64, error) {
+	var base int64
+	switch whence {
+	case io.SeekCurrent:
+		base = int64(f.index)
+	case io.SeekEnd:
+		base = int64(f.buffer.Get("size").Int())
+	}
+	if base+offset < 0 {
+		return 0, errors.New("explorer: seek to a negative position")
+	}
+	f.index = int(base + offset)
+	return int64(f.index), nil
+}
+
 func (f *FileReader) Close() error {
 	if f == nil || f.isClosed {
 		return io.ErrClosedPipe
@@ -116,6 +159,18 @@ func (f *FileReader) Close() error {
 	return nil
 }
 
+// newJSFile returns a File reading the JavaScript File v.
+func newJSFile(v js.Value) *File {
+	return &File{
+		file:     newFileReader(v),
+		name:     v.Get("name").String(),
+		mimeType: v.Get("type").String(),
+		size:     int64(v.Get("size").Int()),
+		// The modification time is in milliseconds since the epoch.
+		modTime: time.UnixMilli(int64(v.Get("lastModified").Int())),
+	}
+}
+
 type FileWriter struct {
 	buffers                  []js.Value
 	isClosed                 bool
@@ -194,12 +249,12 @@ func openCallback(r chan result) js.Func {
 			r <- result{error: ErrUserDecline}
 			return nil
 		}
-		r <- result{file: newFileReader(files.Index(0))}
+		r <- result{file: newJSFile(files.Index(0))}
 		return nil
 	})
 }
 
 var (
-	_ io.ReadCloser  = (*FileReader)(nil)
-	_ io.WriteCloser = (*FileWriter)(nil)
+	_ io.ReadSeekCloser = (*FileReader)(nil)
+	_ io.WriteCloser    = (*FileWriter)(nil)
 )
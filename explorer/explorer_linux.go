@@ -6,18 +6,22 @@
 package explorer
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
 	"github.com/godbus/dbus/v5"
+	"golang.org/x/sys/unix"
 )
 
 // explorer opens file explorers using the xdg-desktop-portal dbus protocol
@@ -25,6 +29,7 @@ import (
 // https://flatpak.github.io/xdg-desktop-portal/#gdbus-org.freedesktop.portal.FileChooser
 type explorer struct {
 	X11Window uintptr
+	wayland   waylandView
 }
 
 func newExplorer(w *app.Window) *explorer {
@@ -35,6 +40,8 @@ func (e *Explorer) listenEvents(ev event.Event) {
 	switch ev := ev.(type) {
 	case app.X11ViewEvent:
 		e.X11Window = ev.Window
+	case app.WaylandViewEvent:
+		e.wayland.setView(ev)
 	}
 }
 
@@ -73,32 +80,30 @@ func extractURIsFromSignal(sig *dbus.Signal) []string {
 
 // exportFile requests that a dialog be opened to write a file with the given
 // name somewhere in the filesystem.
-func (e *Explorer) exportFile(fileName string) (io.WriteCloser, error) {
+func (e *Explorer) exportFile(ctx context.Context, opts Options) (io.WriteCloser, error) {
 	var filepath string
 	if err := e.withDesktopPortal(func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
-		// Invoke the OpenFile method.
+		// Invoke the SaveFile method.
 		requestHandle := ""
-		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.SaveFile", 0, config.parentWindow, "Choose Save Location", map[string]dbus.Variant{
+		options := map[string]dbus.Variant{
 			"handle_token": dbus.MakeVariant(config.handleToken),
-			"current_name": dbus.MakeVariant(fileName),
-		}).Store(&requestHandle)
-		if err != nil {
-			return fmt.Errorf("failed to call OpenFile: %w", err)
+			"current_name": dbus.MakeVariant(opts.Name),
 		}
-
-		// Make sure we got the request object's path right. Update our subscription otherwise.
-		if requestHandle != config.expectedRequestHandle {
-			if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbus.ObjectPath(requestHandle))); err != nil {
-				return fmt.Errorf("failed to subscribe to request: %w", err)
-			}
-			// Reset signal handling.
-			signals := make(chan *dbus.Signal, 1)
-			conn.Signal(signals)
-			config.signals = signals
+		addOptions(options, opts)
+		title := opts.Title
+		if title == "" {
+			title = "Choose Save Location"
+		}
+		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.SaveFile", 0, config.parentWindow, title, options).Store(&requestHandle)
+		if err != nil {
+			return callError("SaveFile", err)
 		}
 
 		// Wait for the response from the file dialog.
-		response := <-config.signals
+		response, err := config.wait(ctx, conn, requestHandle)
+		if err != nil {
+			return err
+		}
 		uris := extractURIsFromSignal(response)
 
 		// Error if no files were selected.
@@ -116,7 +121,7 @@ func (e *Explorer) exportFile(fileName string) (io.WriteCloser, error) {
 	}); err != nil {
 		return nil, err
 	}
-	return os.Create(filepath)
+	return createFileOptions(filepath, opts)
 }
 
 // sanitizeSenderName converts the dbusSenderName into the form required in the
@@ -133,6 +138,40 @@ type config struct {
 	signals               chan *dbus.Signal
 }
 
+// wait waits for the response to the request with the given handle. If ctx is
+// done first, it closes the request, dismissing its dialog, and returns
+// ctx.Err().
+func (c config) wait(ctx context.Context, conn *dbus.Conn, requestHandle string) (*dbus.Signal, error) {
+	// Make sure we got the request object's path right. Update our subscription otherwise.
+	if requestHandle != c.expectedRequestHandle {
+		if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbus.ObjectPath(requestHandle))); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to request: %w", err)
+		}
+		// Reset signal handling.
+		signals := make(chan *dbus.Signal, 1)
+		conn.Signal(signals)
+		c.signals = signals
+	}
+
+	for {
+		select {
+		case sig, ok := <-c.signals:
+			if !ok {
+				return nil, errors.New("connection to session bus closed")
+			}
+			if sig.Path == dbus.ObjectPath(requestHandle) && sig.Name == "org.freedesktop.portal.Request.Response" {
+				return sig, nil
+			}
+		case <-ctx.Done():
+			request := conn.Object("org.freedesktop.portal.Desktop", dbus.ObjectPath(requestHandle))
+			if err := request.Call("org.freedesktop.portal.Request.Close", 0).Err; err != nil {
+				return nil, fmt.Errorf("failed to close request: %w", err)
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
 // withDesktopPortal connects to the session dbus and finds the service
 // implementing the freedesktop.org portals. It accepts a function that
 // it will run with access to the connection, portal, and a set of
@@ -141,7 +180,7 @@ func (e *Explorer) withDesktopPortal(work func(conn *dbus.Conn, desktopPortal db
 	// Connect to the session bus.
 	conn, err := dbus.ConnectSessionBus()
 	if err != nil {
-		return fmt.Errorf("unable to connect to session bus: %w", err)
+		return fmt.Errorf("%w: unable to connect to session bus: %w", ErrNotAvailable, err)
 	}
 	defer conn.Close()
 	// Figure out our own connection name.
@@ -152,6 +191,10 @@ func (e *Explorer) withDesktopPortal(work func(conn *dbus.Conn, desktopPortal db
 	parentWindow := ""
 	if e.X11Window != 0 {
 		parentWindow = "x11:" + fmt.Sprintf("%x", e.X11Window)
+	} else if handle, revoke, ok := e.wayland.export(); ok {
+		// The handle must stay valid while the dialog is shown.
+		defer revoke()
+		parentWindow = "wayland:" + handle
 	}
 	handle, err := randString("giox")
 	if err != nil {
@@ -182,63 +225,113 @@ func (e *Explorer) withDesktopPortal(work func(conn *dbus.Conn, desktopPortal db
 	return nil
 }
 
-// makeFilter constructs a file type filter appropriate for the provided extensions
-// and encodes it as a dbus variant.
-func makeFilter(extensions []string) dbus.Variant {
-	// Resolve the provided extensions to their corresponding mime types.
-	type mimetype struct {
-		// Field names _must_ be exported so that they are available via reflection,
-		// otherwise they will not be sent.
-		Kind uint
-		Name string
-	}
-	mimes := make([]mimetype, len(extensions))
-	for i := range extensions {
-		ext := extensions[i]
-		if !strings.HasPrefix(ext, ".") {
-			ext = "." + ext
+// responseError returns the error reported by the response to a request.
+func responseError(sig *dbus.Signal) error {
+	if len(sig.Body) == 0 {
+		return nil
+	}
+	switch code, _ := sig.Body[0].(uint32); code {
+	case 0:
+		return nil
+	case 1:
+		return ErrUserDecline
+	default:
+		return errors.New("portal request failed")
+	}
+}
+
+// callError wraps the error of calling the portal method. The error matches
+// ErrNotAvailable if no portal is running, or the portal doesn't implement
+// the method.
+func callError(method string, err error) error {
+	var derr dbus.Error
+	if errors.As(err, &derr) {
+		switch derr.Name {
+		case "org.freedesktop.DBus.Error.ServiceUnknown",
+			"org.freedesktop.DBus.Error.NameHasNoOwner",
+			"org.freedesktop.DBus.Error.UnknownInterface",
+			"org.freedesktop.DBus.Error.UnknownMethod":
+			return fmt.Errorf("%w: failed to call %s: %w", ErrNotAvailable, method, err)
 		}
-		mt := mime.TypeByExtension(ext)
-		if mt != "" {
-			mimes[i] = mimetype{
-				Kind: 1,
-				Name: mt,
-			}
+	}
+	return fmt.Errorf("failed to call %s: %w", method, err)
+}
+
+// mimetype is a file type in the form expected by the portal.
+type mimetype struct {
+	// Field names _must_ be exported so that they are available via reflection,
+	// otherwise they will not be sent.
+	Kind uint
+	Name string
+}
+
+// portalFilter is a named file type filter in the form expected by the portal.
+type portalFilter struct {
+	// Field names must be exported so they are available via reflection, otherwise
+	// they will not be sent.
+	Name  string
+	Value []mimetype
+}
+
+// makeFilter constructs a file type filter appropriate for the provided filter.
+// Extensions are resolved to their corresponding MIME types where possible.
+func makeFilter(f Filter) portalFilter {
+	var mimes []mimetype
+	var patterns []string
+	for _, mt := range f.MIMETypes {
+		mimes = append(mimes, mimetype{Kind: 1, Name: mt})
+		patterns = append(patterns, mt)
+	}
+	for _, ext := range f.Extensions {
+		ext = normalizeExtension(ext)
+		if mt := mime.TypeByExtension(ext); mt != "" {
+			mimes = append(mimes, mimetype{Kind: 1, Name: mt})
 		} else {
-			mimes[i] = mimetype{
-				Kind: 0,
-				Name: "*" + ext,
-			}
+			mimes = append(mimes, mimetype{Kind: 0, Name: "*" + ext})
 		}
+		patterns = append(patterns, "*"+ext)
+	}
+	name := f.Name
+	if name == "" {
+		name = strings.Join(patterns, ", ")
 	}
+	return portalFilter{Name: name, Value: mimes}
+}
 
-	// Transform the filter into its dbus variant form.
-	filter := []struct {
-		// Field names must be exported so they are available via reflection, otherwise
-		// they will not be sent.
-		Name  string
-		Value []mimetype
-	}{
-		{
-			Name:  "Filter",
-			Value: mimes,
-		},
+// addOptions adds the portal options corresponding to opts to options.
+func addOptions(options map[string]dbus.Variant, opts Options) {
+	if opts.AcceptLabel != "" {
+		options["accept_label"] = dbus.MakeVariant(opts.AcceptLabel)
+	}
+	if opts.Folder != "" {
+		// The folder is a null-terminated byte string.
+		options["current_folder"] = dbus.MakeVariant(append([]byte(opts.Folder), 0))
+	}
+	if len(opts.Filters) == 0 {
+		return
+	}
+	filters := make([]portalFilter, len(opts.Filters))
+	for i, f := range opts.Filters {
+		filters[i] = makeFilter(f)
+	}
+	options["filters"] = dbus.MakeVariantWithSignature(filters, dbus.ParseSignatureMust("a(sa(us))"))
+	if i := opts.DefaultFilter; i > 0 && i < len(filters) {
+		options["current_filter"] = dbus.MakeVariantWithSignature(filters[i], dbus.ParseSignatureMust("(sa(us))"))
 	}
-	return dbus.MakeVariantWithSignature(filter, dbus.ParseSignatureMust("a(sa(us))"))
 }
 
 type configOpen struct {
-	label      string
-	extensions []string
-	multi      bool
-	dir        bool
+	label string
+	opts  Options
+	multi bool
+	dir   bool
 }
 
 // importFile opens a file picker to choose a file.
-func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
-	vs, err := e.open(configOpen{
-		label:      "Choose File",
-		extensions: extensions,
+func (e *Explorer) importFile(ctx context.Context, opts Options) (io.ReadCloser, error) {
+	vs, err := e.open(ctx, configOpen{
+		label: "Choose File",
+		opts:  opts,
 	})
 	if err != nil {
 		return nil, err
@@ -247,11 +340,11 @@ func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
 }
 
 // importFiles opens a multi-file picker to choose multiple files.
-func (e *Explorer) importFiles(extensions ...string) ([]io.ReadCloser, error) {
-	vs, err := e.open(configOpen{
-		label:      "Choose Files",
-		extensions: extensions,
-		multi:      true,
+func (e *Explorer) importFiles(ctx context.Context, opts Options) ([]io.ReadCloser, error) {
+	vs, err := e.open(ctx, configOpen{
+		label: "Choose Files",
+		opts:  opts,
+		multi: true,
 	})
 	if err != nil {
 		return nil, err
@@ -260,10 +353,145 @@ func (e *Explorer) importFiles(extensions ...string) ([]io.ReadCloser, error) {
 }
 
 func (e *Explorer) readFile(uri string) (io.ReadCloser, error) {
-	return os.Open(uri)
+	return openFile(uri)
+}
+
+// chooseFolder opens a folder picker to choose a folder.
+func (e *Explorer) chooseFolder(ctx context.Context, opts Options) (string, error) {
+	paths, err := e.choose(ctx, configOpen{
+		label: "Choose Folder",
+		opts:  opts,
+		dir:   true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// openURI opens uri through the OpenURI portal. Files are passed to the
+// portal as file descriptors, since it doesn't open file URIs.
+func (e *Explorer) openURI(uri string) error {
+	if path, ok := localPath(uri); ok {
+		return e.openPath("OpenFile", path)
+	}
+	return e.withDesktopPortal(func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
+		requestHandle := ""
+		options := map[string]dbus.Variant{
+			"handle_token": dbus.MakeVariant(config.handleToken),
+		}
+		err := desktopPortal.Call("org.freedesktop.portal.OpenURI.OpenURI", 0, config.parentWindow, uri, options).Store(&requestHandle)
+		if err != nil {
+			return callError("OpenURI", err)
+		}
+		response, err := config.wait(context.Background(), conn, requestHandle)
+		if err != nil {
+			return err
+		}
+		return responseError(response)
+	})
+}
+
+// revealFile opens the folder containing the file at path, selecting it,
+// through the OpenURI portal.
+func (e *Explorer) revealFile(path string) error {
+	return e.openPath("OpenDirectory", path)
+}
+
+// openPath calls the OpenURI portal method, OpenFile or OpenDirectory, with a
+// file descriptor for the file at path.
+func (e *Explorer) openPath(method, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return e.withDesktopPortal(func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
+		requestHandle := ""
+		options := map[string]dbus.Variant{
+			"handle_token": dbus.MakeVariant(config.handleToken),
+		}
+		err := desktopPortal.Call("org.freedesktop.portal.OpenURI."+method, 0, config.parentWindow, dbus.UnixFD(f.Fd()), options).Store(&requestHandle)
+		if err != nil {
+			return callError(method, err)
+		}
+		response, err := config.wait(context.Background(), conn, requestHandle)
+		if err != nil {
+			return err
+		}
+		return responseError(response)
+	})
+}
+
+// keepAccess exports the file at path through the Documents portal, if the
+// app is sandboxed. The portal grants the app access to the file again
+// after restarts, at the returned path in the document store.
+func (e *Explorer) keepAccess(path string) (string, error) {
+	if !sandboxed() {
+		return path, nil
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to connect to session bus: %w", ErrNotAvailable, err)
+	}
+	defer conn.Close()
+	documents := conn.Object("org.freedesktop.portal.Documents", "/org/freedesktop/portal/documents")
+	var mountPoint []byte
+	if err := documents.Call("org.freedesktop.portal.Documents.GetMountPoint", 0).Store(&mountPoint); err != nil {
+		return "", callError("GetMountPoint", err)
+	}
+	// The mount point is a null-terminated byte string.
+	root := strings.TrimRight(string(mountPoint), "\x00")
+	if strings.HasPrefix(path, root+"/") {
+		// The file is already in the document store.
+		return path, nil
+	}
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return "", &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer unix.Close(fd)
+	var docID string
+	err = documents.Call("org.freedesktop.portal.Documents.Add", 0, dbus.UnixFD(fd), true, true).Store(&docID)
+	if err != nil {
+		return "", callError("Add", err)
+	}
+	return filepath.Join(root, docID, filepath.Base(path)), nil
 }
 
-func (e *Explorer) open(cfg configOpen) ([]io.ReadCloser, error) {
+// sandboxed reports whether the app runs in a Flatpak or Snap sandbox, where
+// it can only access files granted by the portals.
+func sandboxed() bool {
+	if _, err := os.Stat("/.flatpak-info"); err == nil {
+		return true
+	}
+	return os.Getenv("SNAP") != ""
+}
+
+func (e *Explorer) open(ctx context.Context, cfg configOpen) ([]io.ReadCloser, error) {
+	filepaths, err := e.choose(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rcs := make([]io.ReadCloser, 0, len(filepaths))
+	for _, fname := range filepaths {
+		rc, err := openFile(fname)
+		if err != nil {
+			for _, rc := range rcs {
+				_ = rc.Close()
+			}
+			return nil, err
+		}
+		rcs = append(rcs, rc)
+	}
+
+	return rcs, nil
+}
+
+// choose opens the portal's file picker and returns the paths of the chosen
+// files or folders.
+func (e *Explorer) choose(ctx context.Context, cfg configOpen) ([]string, error) {
 	var filepaths []string
 	if err := e.withDesktopPortal(func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
 		// Invoke the OpenFile method.
@@ -273,27 +501,21 @@ func (e *Explorer) open(cfg configOpen) ([]io.ReadCloser, error) {
 			"multiple":     dbus.MakeVariant(cfg.multi),
 			"directory":    dbus.MakeVariant(cfg.dir),
 		}
-		if len(cfg.extensions) > 0 {
-			options["filters"] = makeFilter(cfg.extensions)
+		addOptions(options, cfg.opts)
+		label := cfg.label
+		if cfg.opts.Title != "" {
+			label = cfg.opts.Title
 		}
-		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.OpenFile", 0, config.parentWindow, cfg.label, options).Store(&requestHandle)
+		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.OpenFile", 0, config.parentWindow, label, options).Store(&requestHandle)
 		if err != nil {
-			return fmt.Errorf("failed to call OpenFile: %w", err)
-		}
-
-		// Make sure we got the request object's path right. Update our subscription otherwise.
-		if requestHandle != config.expectedRequestHandle {
-			if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbus.ObjectPath(requestHandle))); err != nil {
-				return fmt.Errorf("failed to subscribe to request: %w", err)
-			}
-			// Reset signal handling.
-			signals := make(chan *dbus.Signal, 1)
-			conn.Signal(signals)
-			config.signals = signals
+			return callError("OpenFile", err)
 		}
 
 		// Wait for the response from the file dialog.
-		response := <-config.signals
+		response, err := config.wait(ctx, conn, requestHandle)
+		if err != nil {
+			return err
+		}
 		uris := extractURIsFromSignal(response)
 
 		// Error if no files were selected.
@@ -314,18 +536,5 @@ func (e *Explorer) open(cfg configOpen) ([]io.ReadCloser, error) {
 	}); err != nil {
 		return nil, err
 	}
-
-	rcs := make([]io.ReadCloser, 0, len(filepaths))
-	for _, fname := range filepaths {
-		rc, err := os.Open(fname)
-		if err != nil {
-			for _, rc := range rcs {
-				_ = rc.Close()
-			}
-			return nil, err
-		}
-		rcs = append(rcs, rc)
-	}
-
-	return rcs, nil
+	return filepaths, nil
 }
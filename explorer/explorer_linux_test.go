@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build linux && !android
+
+package explorer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gioui.org/x/internal/dbustest"
+	"github.com/godbus/dbus/v5"
+)
+
+// fakePortal implements the FileChooser portal, responding to each request
+// with the next response.
+type fakePortal struct {
+	conn      *dbus.Conn
+	responses []fakeResponse
+	// options are the options of the last request.
+	options map[string]dbus.Variant
+}
+
+type fakeResponse struct {
+	code uint32
+	uris []string
+}
+
+func (p *fakePortal) OpenFile(sender dbus.Sender, parent, title string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	return p.respond(sender, options)
+}
+
+func (p *fakePortal) SaveFile(sender dbus.Sender, parent, title string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	return p.respond(sender, options)
+}
+
+func (p *fakePortal) respond(sender dbus.Sender, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	p.options = options
+	token, _ := options["handle_token"].Value().(string)
+	path := dbus.ObjectPath("/org/freedesktop/portal/desktop/request/" + sanitizeSenderName(string(sender)) + "/" + token)
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+	results := map[string]dbus.Variant{}
+	if resp.uris != nil {
+		results["uris"] = dbus.MakeVariant(resp.uris)
+	}
+	// The explorer subscribed to the response before calling.
+	if err := p.conn.Emit(path, "org.freedesktop.portal.Request.Response", resp.code, results); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return path, nil
+}
+
+// startPortal starts a fake portal on a private session bus.
+func startPortal(t *testing.T, responses ...fakeResponse) *fakePortal {
+	t.Helper()
+	dbustest.StartBus(t)
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	p := &fakePortal{conn: conn, responses: responses}
+	if err := conn.Export(p, "/org/freedesktop/portal/desktop", "org.freedesktop.portal.FileChooser"); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := conn.RequestName("org.freedesktop.portal.Desktop", dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("failed to own portal name: %v", err)
+	}
+	return p
+}
+
+func TestPortalChooseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := startPortal(t, fakeResponse{uris: []string{"file://" + path}})
+	e := NewExplorer(nil)
+	f, err := e.ChooseFileContext(context.Background(), Options{
+		Filters: []Filter{{Name: "Images", Extensions: []string{".png", ".unknown-ext"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "content" {
+		t.Errorf("read %q, want %q", data, "content")
+	}
+	filters, ok := p.options["filters"].Value().([][]interface{})
+	if !ok || len(filters) != 1 || filters[0][0] != "Images" {
+		t.Errorf("unexpected filters %v", p.options["filters"])
+	}
+	// Extensions of known type are matched by their MIME type.
+	if s := p.options["filters"].String(); !strings.Contains(s, `[1, "image/png"]`) || !strings.Contains(s, `[0, "*.unknown-ext"]`) {
+		t.Errorf("unexpected filter types %v", s)
+	}
+}
+
+func TestPortalCreateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	p := startPortal(t, fakeResponse{uris: []string{"file://" + path}})
+	e := NewExplorer(nil)
+	f, err := e.CreateFileContext(context.Background(), Options{Name: "doc.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "saved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "saved" {
+		t.Errorf("file contains %q, want %q", data, "saved")
+	}
+	if name := p.options["current_name"].Value(); name != "doc.txt" {
+		t.Errorf("suggested name %v, want doc.txt", name)
+	}
+}
+
+func TestPortalDecline(t *testing.T) {
+	startPortal(t, fakeResponse{code: 1})
+	e := NewExplorer(nil)
+	_, err := e.ChooseFileContext(context.Background(), Options{})
+	if !errors.Is(err, ErrUserDecline) {
+		t.Errorf("got error %v, want ErrUserDecline", err)
+	}
+}
+
+func TestPortalNotAvailable(t *testing.T) {
+	dbustest.StartBus(t)
+	e := NewExplorer(nil)
+	_, err := e.ChooseFileContext(context.Background(), Options{})
+	if !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("got error %v, want ErrNotAvailable", err)
+	}
+}
@@ -8,17 +8,24 @@ package explorer
 #cgo CFLAGS: -Werror -xobjective-c -fmodules -fobjc-arc
 
 #import <AppKit/AppKit.h>
+#include <stdlib.h>
 
 // Defined on explorer_macos.m file.
-extern void exportFile(CFTypeRef viewRef, char * name, int32_t id);
-extern void importFile(CFTypeRef viewRef, char * ext, int32_t id);
+extern void exportFile(CFTypeRef viewRef, char * name, char * title, char * prompt, char * folder, int32_t id);
+extern void importFile(CFTypeRef viewRef, char * ext, char * title, char * prompt, char * folder, int32_t id);
+extern void chooseFolder(CFTypeRef viewRef, char * title, char * prompt, char * folder, int32_t id);
+extern void cancelPanel(CFTypeRef viewRef);
+extern bool openURL(char * uri);
+extern void revealFile(char * path);
 */
 import "C"
 import (
+	"context"
 	"io"
 	"net/url"
-	"os"
+	"path/filepath"
 	"strings"
+	"unsafe"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
@@ -28,6 +35,8 @@ type explorer struct {
 	window *app.Window
 	view   C.CFTypeRef
 	result chan result
+	// create holds the options of the file being created.
+	create Options
 }
 
 func newExplorer(w *app.Window) *explorer {
@@ -41,11 +50,33 @@ func (e *Explorer) listenEvents(evt event.Event) {
 	}
 }
 
-func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
-	cname := C.CString(name)
-	e.window.Run(func() { C.exportFile(e.view, cname, C.int32_t(e.id)) })
+// panelOptions holds the options of a panel as C strings.
+type panelOptions struct {
+	title, prompt, folder *C.char
+}
+
+func newPanelOptions(opts Options) panelOptions {
+	return panelOptions{
+		title:  C.CString(opts.Title),
+		prompt: C.CString(opts.AcceptLabel),
+		folder: C.CString(opts.Folder),
+	}
+}
+
+func (p panelOptions) free() {
+	C.free(unsafe.Pointer(p.title))
+	C.free(unsafe.Pointer(p.prompt))
+	C.free(unsafe.Pointer(p.folder))
+}
 
-	resp := <-e.result
+func (e *Explorer) exportFile(ctx context.Context, opts Options) (io.WriteCloser, error) {
+	cname := C.CString(opts.Name)
+	popts := newPanelOptions(opts)
+	defer popts.free()
+	e.create = opts
+	e.window.Run(func() { C.exportFile(e.view, cname, popts.title, popts.prompt, popts.folder, C.int32_t(e.id)) })
+
+	resp := e.wait(ctx)
 	if resp.error != nil {
 		return nil, resp.error
 	}
@@ -53,59 +84,137 @@ func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
 
 }
 
-func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
+func (e *Explorer) importFile(ctx context.Context, opts Options) (io.ReadCloser, error) {
+	extensions := opts.Extensions()
 	for i, ext := range extensions {
 		extensions[i] = strings.TrimPrefix(ext, ".")
 	}
 
 	cextensions := C.CString(strings.Join(extensions, ","))
-	e.window.Run(func() { C.importFile(e.view, cextensions, C.int32_t(e.id)) })
+	popts := newPanelOptions(opts)
+	defer popts.free()
+	e.window.Run(func() {
+		C.importFile(e.view, cextensions, popts.title, popts.prompt, popts.folder, C.int32_t(e.id))
+	})
 
-	resp := <-e.result
+	resp := e.wait(ctx)
 	if resp.error != nil {
 		return nil, resp.error
 	}
 	return resp.file.(io.ReadCloser), resp.error
 }
 
+// wait returns the result of the panel shown by the explorer. If ctx is done
+// first, the panel is cancelled, and reports that the user declined.
+func (e *Explorer) wait(ctx context.Context) result {
+	select {
+	case resp := <-e.result:
+		return resp
+	case <-ctx.Done():
+		// The panel reports its result while it's cancelled on the main
+		// thread, so the cancellation can't be waited for here.
+		go e.window.Run(func() { C.cancelPanel(e.view) })
+		return <-e.result
+	}
+}
+
 func (e *Explorer) readFile(uri string) (io.ReadCloser, error) {
-	return os.Open(uri)
+	return openFile(uri)
 }
 
-func (e *Explorer) importFiles(_ ...string) ([]io.ReadCloser, error) {
+func (e *Explorer) importFiles(_ context.Context, _ Options) ([]io.ReadCloser, error) {
 	return nil, ErrNotAvailable
 }
 
+func (e *Explorer) chooseFolder(ctx context.Context, opts Options) (string, error) {
+	popts := newPanelOptions(opts)
+	defer popts.free()
+	e.window.Run(func() { C.chooseFolder(e.view, popts.title, popts.prompt, popts.folder, C.int32_t(e.id)) })
+
+	resp := e.wait(ctx)
+	if resp.error != nil {
+		return "", resp.error
+	}
+	return resp.file.(string), nil
+}
+
+func (e *Explorer) openURI(uri string) error {
+	if path, ok := localPath(uri); ok {
+		uri = fileURI(path)
+	}
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+	var ok bool
+	e.window.Run(func() { ok = bool(C.openURL(curi)) })
+	if !ok {
+		return ErrNotAvailable
+	}
+	return nil
+}
+
+func (e *Explorer) revealFile(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	e.window.Run(func() { C.revealFile(cpath) })
+	return nil
+}
+
+// keepAccess does nothing, since apps can access files by their path.
+func (e *Explorer) keepAccess(path string) (string, error) {
+	return path, nil
+}
+
 //export importCallback
 func importCallback(u *C.char, id int32) {
 	if v, ok := active.Load(id); ok {
-		v.(*explorer).result <- newOSFile(u, os.Open)
+		v.(*explorer).result <- fileResult(u, openFile)
 	}
 }
 
 //export exportCallback
 func exportCallback(u *C.char, id int32) {
 	if v, ok := active.Load(id); ok {
-		v.(*explorer).result <- newOSFile(u, os.Create)
+		e := v.(*explorer)
+		e.result <- fileResult(u, func(path string) (*File, error) {
+			return createFileOptions(path, e.create)
+		})
 	}
 }
 
-func newOSFile(u *C.char, action func(s string) (*os.File, error)) result {
-	name := C.GoString(u)
-	if name == "" {
-		return result{error: ErrUserDecline, file: nil}
+//export folderCallback
+func folderCallback(u *C.char, id int32) {
+	if v, ok := active.Load(id); ok {
+		path, err := filePath(u)
+		// Folder URLs end with a separator.
+		v.(*explorer).result <- result{error: err, file: filepath.Clean(path)}
 	}
+}
 
-	uri, err := url.Parse(name)
+func fileResult(u *C.char, action func(s string) (*File, error)) result {
+	path, err := filePath(u)
 	if err != nil {
 		return result{error: err, file: nil}
 	}
 
-	path, err := url.PathUnescape(uri.Path)
+	f, err := action(path)
+	return result{error: err, file: f}
+}
+
+// filePath converts the file URL u reported by a panel to a path.
+func filePath(u *C.char) (string, error) {
+	name := C.GoString(u)
+	if name == "" {
+		return "", ErrUserDecline
+	}
+
+	uri, err := url.Parse(name)
 	if err != nil {
-		return result{error: err, file: nil}
+		return "", err
 	}
 
-	f, err := action(path)
-	return result{error: err, file: f}
+	return url.PathUnescape(uri.Path)
 }
@@ -8,10 +8,25 @@
 #import <AppKit/AppKit.h>
 #import <UniformTypeIdentifiers/UniformTypeIdentifiers.h>
 
-void exportFile(CFTypeRef viewRef, char * name, int32_t id) {
+static void configurePanel(NSSavePanel *panel, char * title, char * prompt, char * folder) {
+	// Sheets don't show their title, so it's also used as the message.
+	if (title[0] != 0) {
+		[panel setTitle:@(title)];
+		[panel setMessage:@(title)];
+	}
+	if (prompt[0] != 0) {
+		[panel setPrompt:@(prompt)];
+	}
+	if (folder[0] != 0) {
+		[panel setDirectoryURL:[NSURL fileURLWithPath:@(folder) isDirectory:YES]];
+	}
+}
+
+void exportFile(CFTypeRef viewRef, char * name, char * title, char * prompt, char * folder, int32_t id) {
 	NSView *view = (__bridge NSView *)viewRef;
 
 	NSSavePanel *panel = [NSSavePanel savePanel];
+	configurePanel(panel, title, prompt, folder);
 
     [panel setNameFieldStringValue:@(name)];
 	[panel beginSheetModalForWindow:[view window] completionHandler:^(NSInteger result){
@@ -23,10 +38,11 @@ void exportFile(CFTypeRef viewRef, char * name, int32_t id) {
 	}];
 }
 
-void importFile(CFTypeRef viewRef, char * ext, int32_t id) {
+void importFile(CFTypeRef viewRef, char * ext, char * title, char * prompt, char * folder, int32_t id) {
 	NSView *view = (__bridge NSView *)viewRef;
 
 	NSOpenPanel *panel = [NSOpenPanel openPanel];
+	configurePanel(panel, title, prompt, folder);
 
     NSMutableArray<NSString*> *exts = [[@(ext) componentsSeparatedByString:@","] mutableCopy];
     NSMutableArray<UTType*> *contentTypes = [[NSMutableArray alloc]init];
@@ -47,4 +63,43 @@ void importFile(CFTypeRef viewRef, char * ext, int32_t id) {
 		    importCallback((char *)(""), id);
 		}
 	}];
-}
\ No newline at end of file
+}
+
+void chooseFolder(CFTypeRef viewRef, char * title, char * prompt, char * folder, int32_t id) {
+	NSView *view = (__bridge NSView *)viewRef;
+
+	NSOpenPanel *panel = [NSOpenPanel openPanel];
+	configurePanel(panel, title, prompt, folder);
+
+	[panel setCanChooseFiles:NO];
+	[panel setCanChooseDirectories:YES];
+	[panel setCanCreateDirectories:YES];
+	[panel beginSheetModalForWindow:[view window] completionHandler:^(NSInteger result){
+		if (result == NSModalResponseOK) {
+			folderCallback((char *)[[panel URL].absoluteString UTF8String], id);
+		} else {
+		    folderCallback((char *)(""), id);
+		}
+	}];
+}
+
+void cancelPanel(CFTypeRef viewRef) {
+	NSWindow *window = [(__bridge NSView *)viewRef window];
+	NSWindow *sheet = [window attachedSheet];
+	if (sheet != nil) {
+		[window endSheet:sheet returnCode:NSModalResponseCancel];
+	}
+}
+
+bool openURL(char * uri) {
+	NSURL *url = [NSURL URLWithString:@(uri)];
+	if (url == nil) {
+		return NO;
+	}
+	return [[NSWorkspace sharedWorkspace] openURL:url];
+}
+
+void revealFile(char * path) {
+	NSURL *url = [NSURL fileURLWithPath:@(path)];
+	[[NSWorkspace sharedWorkspace] activateFileViewerSelectingURLs:@[url]];
+}
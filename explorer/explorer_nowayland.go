@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build linux && !android && (nowayland || !cgo)
+
+package explorer
+
+import "gioui.org/app"
+
+// waylandView is a no-op without Wayland support.
+type waylandView struct{}
+
+func (w *waylandView) setView(ev app.WaylandViewEvent) {}
+
+func (w *waylandView) export() (string, func(), bool) {
+	return "", nil, false
+}
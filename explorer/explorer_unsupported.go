@@ -6,6 +6,7 @@
 package explorer
 
 import (
+	"context"
 	"io"
 
 	"gioui.org/app"
@@ -22,14 +23,24 @@ func (e *Explorer) listenEvents(_ event.Event) {}
 
 func (e *Explorer) readFile(_ string) (io.ReadCloser, error) { return nil, ErrNotAvailable }
 
-func (e *Explorer) exportFile(_ string) (io.WriteCloser, error) {
+func (e *Explorer) exportFile(_ context.Context, _ Options) (io.WriteCloser, error) {
 	return nil, ErrNotAvailable
 }
 
-func (e *Explorer) importFile(_ ...string) (io.ReadCloser, error) {
+func (e *Explorer) importFile(_ context.Context, _ Options) (io.ReadCloser, error) {
 	return nil, ErrNotAvailable
 }
 
-func (e *Explorer) importFiles(_ ...string) ([]io.ReadCloser, error) {
+func (e *Explorer) importFiles(_ context.Context, _ Options) ([]io.ReadCloser, error) {
 	return nil, ErrNotAvailable
 }
+
+func (e *Explorer) chooseFolder(_ context.Context, _ Options) (string, error) {
+	return "", ErrNotAvailable
+}
+
+func (e *Explorer) openURI(_ string) error { return ErrNotAvailable }
+
+func (e *Explorer) revealFile(_ string) error { return ErrNotAvailable }
+
+func (e *Explorer) keepAccess(_ string) (string, error) { return "", ErrNotAvailable }
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build linux && !android && !nowayland && cgo
+
+#include <stdlib.h>
+#include <string.h>
+#include <wayland-client.h>
+#include "wayland_xdg_foreign.h"
+
+static void registryGlobal(void *data, struct wl_registry *registry, uint32_t name, const char *interface, uint32_t version) {
+	struct zxdg_exporter_v2 **exporter = data;
+	if (*exporter == NULL && strcmp(interface, zxdg_exporter_v2_interface.name) == 0) {
+		*exporter = wl_registry_bind(registry, name, &zxdg_exporter_v2_interface, 1);
+	}
+}
+
+static void registryGlobalRemove(void *data, struct wl_registry *registry, uint32_t name) {
+}
+
+static const struct wl_registry_listener registryListener = {
+	.global = registryGlobal,
+	.global_remove = registryGlobalRemove,
+};
+
+static void exportedHandle(void *data, struct zxdg_exported_v2 *exported, const char *handle) {
+	char **result = data;
+	if (result != NULL && *result == NULL) {
+		*result = strdup(handle);
+	}
+}
+
+static const struct zxdg_exported_v2_listener exportedListener = {
+	.handle = exportedHandle,
+};
+
+// bindExporter returns the zxdg_exporter_v2 global of display, or NULL if
+// the compositor doesn't support xdg-foreign. The events of the exporter
+// and its objects are dispatched to queue, instead of the queue of the
+// window.
+struct zxdg_exporter_v2 *bindExporter(struct wl_display *display, struct wl_event_queue *queue) {
+	struct wl_display *wrapper = wl_proxy_create_wrapper(display);
+	if (wrapper == NULL) {
+		return NULL;
+	}
+	wl_proxy_set_queue((struct wl_proxy *)wrapper, queue);
+	struct wl_registry *registry = wl_display_get_registry(wrapper);
+	wl_proxy_wrapper_destroy(wrapper);
+	struct zxdg_exporter_v2 *exporter = NULL;
+	wl_registry_add_listener(registry, &registryListener, &exporter);
+	wl_display_roundtrip_queue(display, queue);
+	wl_registry_destroy(registry);
+	return exporter;
+}
+
+// exportSurface exports surface and stores its handle in handle, which the
+// caller must free. It returns the exported object, which keeps the handle
+// valid until it's destroyed.
+struct zxdg_exported_v2 *exportSurface(struct wl_display *display, struct wl_event_queue *queue, struct zxdg_exporter_v2 *exporter, struct wl_surface *surface, char **handle) {
+	*handle = NULL;
+	struct zxdg_exported_v2 *exported = zxdg_exporter_v2_export_toplevel(exporter, surface);
+	zxdg_exported_v2_add_listener(exported, &exportedListener, handle);
+	// The compositor sends the handle immediately.
+	wl_display_roundtrip_queue(display, queue);
+	zxdg_exported_v2_set_user_data(exported, NULL);
+	return exported;
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build linux && !android && !nowayland && cgo
+
+package explorer
+
+/*
+#cgo linux pkg-config: wayland-client
+
+#include <stdlib.h>
+#include <wayland-client.h>
+#include "wayland_xdg_foreign.h"
+
+// Defined on explorer_wayland.c file.
+extern struct zxdg_exporter_v2 *bindExporter(struct wl_display *display, struct wl_event_queue *queue);
+extern struct zxdg_exported_v2 *exportSurface(struct wl_display *display, struct wl_event_queue *queue, struct zxdg_exporter_v2 *exporter, struct wl_surface *surface, char **handle);
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+
+	"gioui.org/app"
+)
+
+// waylandView exports the surface of a Wayland window with the xdg-foreign
+// protocol, for the portals to make it the parent of their dialogs.
+type waylandView struct {
+	mu       sync.Mutex
+	display  *C.struct_wl_display
+	surface  *C.struct_wl_surface
+	queue    *C.struct_wl_event_queue
+	exporter *C.struct_zxdg_exporter_v2
+	// exported are the exports of the dialogs being shown.
+	exported map[*C.struct_zxdg_exported_v2]bool
+}
+
+// setView records the window of ev. The objects created for the previous
+// window are destroyed first, since its display is disconnected after an
+// invalid event.
+func (w *waylandView) setView(ev app.WaylandViewEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.destroy()
+	w.display = (*C.struct_wl_display)(ev.Display)
+	w.surface = (*C.struct_wl_surface)(ev.Surface)
+}
+
+func (w *waylandView) destroy() {
+	for exported := range w.exported {
+		C.zxdg_exported_v2_destroy(exported)
+	}
+	w.exported = nil
+	if w.exporter != nil {
+		C.zxdg_exporter_v2_destroy(w.exporter)
+		w.exporter = nil
+	}
+	if w.queue != nil {
+		C.wl_event_queue_destroy(w.queue)
+		w.queue = nil
+	}
+	if w.display != nil {
+		C.wl_display_flush(w.display)
+	}
+	w.display = nil
+	w.surface = nil
+}
+
+// export exports the window and returns its handle, and a function revoking
+// the handle when the dialog is closed. It reports false if there's no
+// Wayland window, or the compositor doesn't support xdg-foreign.
+func (w *waylandView) export() (string, func(), bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.surface == nil {
+		return "", nil, false
+	}
+	if w.queue == nil {
+		w.queue = C.wl_display_create_queue(w.display)
+		w.exporter = C.bindExporter(w.display, w.queue)
+	}
+	if w.exporter == nil {
+		return "", nil, false
+	}
+	var handle *C.char
+	exported := C.exportSurface(w.display, w.queue, w.exporter, w.surface, &handle)
+	if handle == nil {
+		C.zxdg_exported_v2_destroy(exported)
+		return "", nil, false
+	}
+	defer C.free(unsafe.Pointer(handle))
+	if w.exported == nil {
+		w.exported = make(map[*C.struct_zxdg_exported_v2]bool)
+	}
+	w.exported[exported] = true
+	revoke := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		// The export is already destroyed if the window was.
+		if !w.exported[exported] {
+			return
+		}
+		delete(w.exported, exported)
+		C.zxdg_exported_v2_destroy(exported)
+		C.wl_display_flush(w.display)
+	}
+	return C.GoString(handle), revoke, true
+}
@@ -3,10 +3,14 @@
 package explorer
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
+	"time"
 	"unsafe"
 
 	"gioui.org/app"
@@ -21,6 +25,32 @@ var (
 	_GetSaveFileName = _Dialog32.NewProc("GetSaveFileNameW")
 	_GetOpenFileName = _Dialog32.NewProc("GetOpenFileNameW")
 
+	// https://learn.microsoft.com/en-us/windows/win32/api/shobjidl_core/
+	_Shell32 = windows.NewLazySystemDLL("shell32.dll")
+
+	_SHCreateItemFromParsingName = _Shell32.NewProc("SHCreateItemFromParsingName")
+
+	// https://learn.microsoft.com/en-us/windows/win32/api/combaseapi/
+	_Ole32 = windows.NewLazySystemDLL("ole32.dll")
+
+	_CoCreateInstance = _Ole32.NewProc("CoCreateInstance")
+	_CoTaskMemFree    = _Ole32.NewProc("CoTaskMemFree")
+
+	// https://learn.microsoft.com/en-us/windows/win32/api/winuser/
+	_User32 = windows.NewLazySystemDLL("user32.dll")
+
+	_EnumThreadWindows = _User32.NewProc("EnumThreadWindows")
+	_PostMessage       = _User32.NewProc("PostMessageW")
+
+	// WM_COMMAND with IDCANCEL presses the Cancel button of a dialog box.
+	_MessageCommand = uintptr(0x0111)
+	_IDCancel       = uintptr(2)
+	// _DialogClass is the window class of dialog boxes.
+	_DialogClass = "#32770"
+
+	// cancelDialogCallback is cancelDialog as an EnumThreadWindows callback.
+	cancelDialogCallback = syscall.NewCallback(cancelDialog)
+
 	// https://docs.microsoft.com/en-us/windows/win32/api/commdlg/ns-commdlg-openfilenamew
 	_FlagFileMustExist    = uint32(0x00001000)
 	_FlagForceShowHidden  = uint32(0x10000000)
@@ -34,6 +64,21 @@ var (
 	// application resolves after the dialog closes.
 	_FlagNoChangeDir = uint32(0x00000008)
 
+	// https://learn.microsoft.com/en-us/windows/win32/api/shobjidl_core/ne-shobjidl_core-_fileopendialogoptions
+	_FlagPickFolders     = uint32(0x00000020)
+	_FlagForceFileSystem = uint32(0x00000040)
+
+	// https://learn.microsoft.com/en-us/windows/win32/api/shobjidl_core/nn-shobjidl_core-ifileopendialog
+	_CLSIDFileOpenDialog = windows.GUID{Data1: 0xdc1c5a9c, Data2: 0xe88a, Data3: 0x4dde, Data4: [8]byte{0xa5, 0xa1, 0x60, 0xf8, 0x2a, 0x20, 0xae, 0xf7}}
+	_IIDFileOpenDialog   = windows.GUID{Data1: 0xd57c7288, Data2: 0xd4ad, Data3: 0x4768, Data4: [8]byte{0xbe, 0x02, 0x9d, 0x96, 0x95, 0x32, 0xd9, 0x60}}
+	_IIDShellItem        = windows.GUID{Data1: 0x43826d1e, Data2: 0xe718, Data3: 0x42ee, Data4: [8]byte{0xbc, 0x55, 0xa1, 0xe2, 0x61, 0xc3, 0x7b, 0xfe}}
+
+	_ClassContextInprocServer = uintptr(0x1)
+	_SIGDNFileSysPath         = uintptr(0x80058000)
+	// HRESULT_FROM_WIN32(ERROR_CANCELLED), returned when the dialog is
+	// cancelled.
+	_ResultCancelled = uintptr(0x800704c7)
+
 	_FilePathLength       = uint32(65535)
 	_OpenFileStructLength = uint32(unsafe.Sizeof(_OpenFileName{}))
 )
@@ -65,32 +110,73 @@ type (
 		DwReserved      uint32
 		FlagsEx         uint32
 	}
+
+	// _ComObject is a COM object, such as an IFileOpenDialog or an
+	// IShellItem, whose first field points to the table of its methods.
+	_ComObject struct {
+		vtbl *[_MethodCount]uintptr
+	}
 )
 
-type explorer struct{}
+// Indices of the methods of IFileOpenDialog and IShellItem, in the order of
+// their declaration in shobjidl_core.h.
+const (
+	_MethodRelease = 2
+
+	// IFileOpenDialog
+	_MethodShow             = 3
+	_MethodSetOptions       = 9
+	_MethodGetOptions       = 10
+	_MethodSetFolder        = 12
+	_MethodSetTitle         = 17
+	_MethodSetOkButtonLabel = 18
+	_MethodGetResult        = 20
+	_MethodClose            = 23
+
+	// IShellItem
+	_MethodGetDisplayName = 5
+
+	_MethodCount = 29
+)
+
+type explorer struct {
+	// hwnd is the window that owns the dialogs.
+	hwnd uintptr
+}
 
 func newExplorer(_ *app.Window) *explorer {
 	return &explorer{}
 }
 
 func (e *Explorer) listenEvents(evt event.Event) {
-	// NO-OP
+	switch evt := evt.(type) {
+	case app.Win32ViewEvent:
+		e.hwnd = evt.HWND
+	}
 }
 
-func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
+func (e *Explorer) exportFile(ctx context.Context, opts Options) (io.WriteCloser, error) {
+	name := opts.Name
 	pathUTF16 := make([]uint16, _FilePathLength)
 	copy(pathUTF16, windows.StringToUTF16(name))
 
+	if ext := filepath.Ext(name); ext != "" && len(opts.Filters) == 0 {
+		opts.Filters = []Filter{{Extensions: []string{ext}}}
+	}
 	open := _OpenFileName{
+		Owner:         e.hwnd,
 		File:          &pathUTF16[0],
 		MaxFile:       _FilePathLength,
-		Filter:        buildFilter([]string{filepath.Ext(name)}),
+		Filter:        buildFilter(opts.Filters),
+		FilterIndex:   uint32(opts.DefaultFilter + 1),
+		Title:         utf16Ptr(opts.Title),
+		InitialDir:    utf16Ptr(opts.Folder),
 		FileExtension: uint16(strings.Index(name, filepath.Ext(name))),
 		Flags:         _FlagOverwritePrompt | _FlagNoChangeDir,
 		StructSize:    _OpenFileStructLength,
 	}
 
-	if r, _, _ := _GetSaveFileName.Call(uintptr(unsafe.Pointer(&open))); r == 0 {
+	if !showDialog(ctx, _GetSaveFileName, &open) {
 		return nil, ErrUserDecline
 	}
 
@@ -99,21 +185,25 @@ func (e *Explorer) exportFile(name string) (io.WriteCloser, error) {
 		return nil, ErrUserDecline
 	}
 
-	return os.Create(path)
+	return createFileOptions(path, opts)
 }
 
-func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
+func (e *Explorer) importFile(ctx context.Context, opts Options) (io.ReadCloser, error) {
 	pathUTF16 := make([]uint16, _FilePathLength)
 
 	open := _OpenFileName{
-		File:       &pathUTF16[0],
-		MaxFile:    _FilePathLength,
-		Filter:     buildFilter(extensions),
-		Flags:      _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks | _FlagNoChangeDir,
-		StructSize: _OpenFileStructLength,
+		Owner:       e.hwnd,
+		File:        &pathUTF16[0],
+		MaxFile:     _FilePathLength,
+		Filter:      buildFilter(opts.Filters),
+		FilterIndex: uint32(opts.DefaultFilter + 1),
+		Title:       utf16Ptr(opts.Title),
+		InitialDir:  utf16Ptr(opts.Folder),
+		Flags:       _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks | _FlagNoChangeDir,
+		StructSize:  _OpenFileStructLength,
 	}
 
-	if r, _, _ := _GetOpenFileName.Call(uintptr(unsafe.Pointer(&open))); r == 0 {
+	if !showDialog(ctx, _GetOpenFileName, &open) {
 		return nil, ErrUserDecline
 	}
 
@@ -122,25 +212,29 @@ func (e *Explorer) importFile(extensions ...string) (io.ReadCloser, error) {
 		return nil, ErrUserDecline
 	}
 
-	return os.Open(path)
+	return openFile(path)
 }
 
 func (e *Explorer) readFile(uri string) (io.ReadCloser, error) {
-	return os.Open(uri)
+	return openFile(uri)
 }
 
-func (e *Explorer) importFiles(extensions ...string) ([]io.ReadCloser, error) {
+func (e *Explorer) importFiles(ctx context.Context, opts Options) ([]io.ReadCloser, error) {
 	pathUTF16 := make([]uint16, _FilePathLength)
 
 	open := _OpenFileName{
-		File:       &pathUTF16[0],
-		MaxFile:    _FilePathLength,
-		Filter:     buildFilter(extensions),
-		Flags:      _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks | _FlagAllowMultiSelect | _FlagExplorer | _FlagNoChangeDir,
-		StructSize: _OpenFileStructLength,
+		Owner:       e.hwnd,
+		File:        &pathUTF16[0],
+		MaxFile:     _FilePathLength,
+		Filter:      buildFilter(opts.Filters),
+		FilterIndex: uint32(opts.DefaultFilter + 1),
+		Title:       utf16Ptr(opts.Title),
+		InitialDir:  utf16Ptr(opts.Folder),
+		Flags:       _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks | _FlagAllowMultiSelect | _FlagExplorer | _FlagNoChangeDir,
+		StructSize:  _OpenFileStructLength,
 	}
 
-	if r, _, _ := _GetOpenFileName.Call(uintptr(unsafe.Pointer(&open))); r == 0 {
+	if !showDialog(ctx, _GetOpenFileName, &open) {
 		return nil, ErrUserDecline
 	}
 
@@ -171,7 +265,7 @@ func (e *Explorer) importFiles(extensions ...string) ([]io.ReadCloser, error) {
 
 	files := make([]io.ReadCloser, len(filePaths))
 	for i, filePath := range filePaths {
-		file, err := os.Open(filePath)
+		file, err := openFile(filePath)
 		if err != nil {
 			for _, file := range files {
 				if file != nil {
@@ -186,24 +280,192 @@ func (e *Explorer) importFiles(extensions ...string) ([]io.ReadCloser, error) {
 	return files, nil
 }
 
-func buildFilter(extensions []string) *uint16 {
-	if len(extensions) <= 0 {
-		return nil
+func (e *Explorer) openURI(uri string) error {
+	if path, ok := localPath(uri); ok {
+		uri = path
+	}
+	return windows.ShellExecute(0, windows.StringToUTF16Ptr("open"), windows.StringToUTF16Ptr(uri), nil, nil, windows.SW_SHOWNORMAL)
+}
+
+func (e *Explorer) revealFile(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	args := fmt.Sprintf(`/select,"%s"`, path)
+	return windows.ShellExecute(0, windows.StringToUTF16Ptr("open"), windows.StringToUTF16Ptr("explorer.exe"), windows.StringToUTF16Ptr(args), nil, windows.SW_SHOWNORMAL)
+}
+
+// keepAccess does nothing, since apps can access files by their path.
+func (e *Explorer) keepAccess(path string) (string, error) {
+	return path, nil
+}
+
+// chooseFolder shows an IFileOpenDialog picking folders, modal to the window.
+func (e *Explorer) chooseFolder(ctx context.Context, opts Options) (string, error) {
+	// The dialog is a COM object, used on the calling thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	switch err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED); err {
+	case nil, syscall.Errno(1): // S_FALSE: COM is already initialized.
+		defer windows.CoUninitialize()
+	}
+
+	var dialog *_ComObject
+	if r, _, _ := _CoCreateInstance.Call(uintptr(unsafe.Pointer(&_CLSIDFileOpenDialog)), 0, _ClassContextInprocServer, uintptr(unsafe.Pointer(&_IIDFileOpenDialog)), uintptr(unsafe.Pointer(&dialog))); failed(r) {
+		return "", fmt.Errorf("explorer: creating the folder dialog failed: %#x", r)
 	}
+	defer dialog.call(_MethodRelease)
 
-	for k, v := range extensions {
-		// Extension must have `*` wildcard, so `.jpg` must be `*.jpg`.
-		if !strings.HasPrefix(v, "*") {
-			extensions[k] = "*" + v
+	var flags uint32
+	dialog.call(_MethodGetOptions, uintptr(unsafe.Pointer(&flags)))
+	dialog.call(_MethodSetOptions, uintptr(flags|_FlagPickFolders|_FlagForceFileSystem|_FlagNoChangeDir))
+	if opts.Title != "" {
+		dialog.call(_MethodSetTitle, uintptr(unsafe.Pointer(utf16Ptr(opts.Title))))
+	}
+	if opts.AcceptLabel != "" {
+		dialog.call(_MethodSetOkButtonLabel, uintptr(unsafe.Pointer(utf16Ptr(opts.AcceptLabel))))
+	}
+	if opts.Folder != "" {
+		var folder *_ComObject
+		if r, _, _ := _SHCreateItemFromParsingName.Call(uintptr(unsafe.Pointer(utf16Ptr(opts.Folder))), 0, uintptr(unsafe.Pointer(&_IIDShellItem)), uintptr(unsafe.Pointer(&folder))); !failed(r) {
+			dialog.call(_MethodSetFolder, uintptr(unsafe.Pointer(folder)))
+			folder.call(_MethodRelease)
 		}
 	}
-	e := strings.ToUpper(strings.Join(extensions, ";"))
 
-	// That is a "string-pair", Windows have a Title and the Filter, for instance it could be:
+	stop := closeOnDone(ctx, func() { dialog.call(_MethodClose, _ResultCancelled) })
+	r := dialog.call(_MethodShow, e.hwnd)
+	stop()
+	switch {
+	case r == _ResultCancelled:
+		return "", ErrUserDecline
+	case failed(r):
+		return "", fmt.Errorf("explorer: showing the folder dialog failed: %#x", r)
+	}
+
+	var item *_ComObject
+	if r := dialog.call(_MethodGetResult, uintptr(unsafe.Pointer(&item))); failed(r) {
+		return "", ErrUserDecline
+	}
+	defer item.call(_MethodRelease)
+	var path *uint16
+	if r := item.call(_MethodGetDisplayName, _SIGDNFileSysPath, uintptr(unsafe.Pointer(&path))); failed(r) {
+		// The folder isn't part of the file system.
+		return "", ErrUserDecline
+	}
+	defer _CoTaskMemFree.Call(uintptr(unsafe.Pointer(path)))
+
+	return windows.UTF16PtrToString(path), nil
+}
+
+// showDialog shows the dialog of the common dialog function proc, such as
+// GetOpenFileNameW, configured by open. It reports whether the user accepted
+// a file. The dialog is cancelled when ctx is done.
+func showDialog(ctx context.Context, proc *windows.LazyProc, open *_OpenFileName) bool {
+	// The dialog is a window of the calling thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	thread := windows.GetCurrentThreadId()
+	stop := closeOnDone(ctx, func() {
+		_EnumThreadWindows.Call(uintptr(thread), cancelDialogCallback, 0)
+	})
+	r, _, _ := proc.Call(uintptr(unsafe.Pointer(open)))
+	stop()
+	return r != 0
+}
+
+// cancelDialog cancels hwnd if it's a dialog box. It's called for each
+// window of the thread showing a dialog.
+func cancelDialog(hwnd, _ uintptr) uintptr {
+	class := make([]uint16, len(_DialogClass)+2)
+	if n, _ := windows.GetClassName(windows.HWND(hwnd), &class[0], int32(len(class))); windows.UTF16ToString(class[:n]) == _DialogClass {
+		_PostMessage.Call(hwnd, _MessageCommand, _IDCancel, 0)
+	}
+	return 1 // Continue with the next window.
+}
+
+// closeOnDone calls closeDialog once ctx is done, until the returned function
+// is called after the dialog returns. closeDialog is called repeatedly,
+// because the dialog may not be shown yet when ctx is done.
+func closeOnDone(ctx context.Context, closeDialog func()) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	stopped := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer func() { exited <- struct{}{} }()
+		select {
+		case <-stopped:
+			return
+		case <-ctx.Done():
+		}
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			closeDialog()
+			select {
+			case <-stopped:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		stopped <- struct{}{}
+		<-exited
+	}
+}
+
+// call calls the method of o with the index i, and returns its HRESULT.
+//
+//go:uintptrescapes
+func (o *_ComObject) call(i int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(o.vtbl[i], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	return r
+}
+
+// failed reports whether the HRESULT r is an error.
+func failed(r uintptr) bool {
+	return int32(r) < 0
+}
+
+func buildFilter(filters []Filter) *uint16 {
+	if len(filters) <= 0 {
+		return nil
+	}
+
+	// That is a list of "string-pairs", Windows have a Title and the Filter, for instance it could be:
 	// Images\0*.JPG;*.PNG\0\0
 	// Where `\0` means NULL
-	f := windows.StringToUTF16(e + " " + e) // Use the filter as title so it appear `*.JPG;*.PNG` for the user.
-	f[len(e)] = 0                           // Replace the " " (space) with NULL.
-	f = append(f, uint16(0))                // Adding another NULL, because we need two.
+	var f []uint16
+	for _, filter := range filters {
+		exts := filter.extensions()
+		patterns := make([]string, len(exts))
+		for i, ext := range exts {
+			// Extension must have `*` wildcard, so `.jpg` must be `*.jpg`.
+			patterns[i] = "*" + ext
+		}
+		p := strings.ToUpper(strings.Join(patterns, ";"))
+		if p == "" {
+			p = "*.*"
+		}
+		title := filter.Name
+		if title == "" {
+			title = p // Use the filter as title so it appear `*.JPG;*.PNG` for the user.
+		}
+		f = append(f, windows.StringToUTF16(title)...) // StringToUTF16 includes the NULL.
+		f = append(f, windows.StringToUTF16(p)...)
+	}
+	f = append(f, uint16(0)) // Adding another NULL, because we need two.
 	return &f[0]
 }
+
+// utf16Ptr returns s as a NULL-terminated UTF-16 string, or nil if s is empty.
+func utf16Ptr(s string) *uint16 {
+	if s == "" {
+		return nil
+	}
+	return &windows.StringToUTF16(s)[0]
+}
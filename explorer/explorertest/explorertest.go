@@ -0,0 +1,329 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package explorertest implements a fake explorer.Dialogs, for testing code
+// that shows file dialogs without showing them.
+//
+// The fake returns scripted results, in the order they're added:
+//
+//	fake := new(explorertest.Fake)
+//	out := explorertest.NewFile("out.txt", nil)
+//	fake.Respond(out)
+//	fake.Decline()
+//
+//	save(fake) // Calls fake.CreateFileContext, and writes to out.
+//	if string(out.Bytes()) != "saved" {
+//		t.Error("unexpected file contents")
+//	}
+//
+// Like the files of an explorer.Explorer, the files returned by a Fake are
+// *explorer.File values, which read and write the scripted files.
+package explorertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+
+	"gioui.org/x/explorer"
+)
+
+// Kind is the kind of a request.
+type Kind uint8
+
+const (
+	ChooseFile Kind = iota
+	ChooseFiles
+	ChooseFolder
+	CreateFile
+	ReadFile
+)
+
+// Request records a call of a Fake.
+type Request struct {
+	Kind Kind
+	// Options are the options of the dialog.
+	Options explorer.Options
+	// Extensions are the extensions accepted by the dialog, as returned by
+	// Options.Extensions.
+	Extensions []string
+	// URI is the URI read by ReadFile.
+	URI string
+}
+
+// Fake implements explorer.Dialogs. Each dialog returns the next scripted
+// result, and declines once no results are left. Its methods can be called
+// concurrently.
+type Fake struct {
+	mu       sync.Mutex
+	results  []result
+	requests []Request
+	files    map[string][]byte
+}
+
+type result struct {
+	files  []*File
+	folder string
+	err    error
+}
+
+var _ explorer.Dialogs = (*Fake)(nil)
+
+// Respond adds a result choosing files. ChooseFile and CreateFile return
+// the first file.
+func (f *Fake) Respond(files ...*File) {
+	f.add(result{files: files})
+}
+
+// RespondFolder adds a result choosing the folder at path.
+func (f *Fake) RespondFolder(path string) {
+	f.add(result{folder: path})
+}
+
+// Decline adds a result where the user declines the dialog.
+func (f *Fake) Decline() {
+	f.add(result{err: explorer.ErrUserDecline})
+}
+
+// Fail adds a result failing with err.
+func (f *Fake) Fail(err error) {
+	f.add(result{err: err})
+}
+
+// Store stores data for ReadFile to return for uri.
+func (f *Fake) Store(uri string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files == nil {
+		f.files = make(map[string][]byte)
+	}
+	f.files[uri] = data
+}
+
+// Requests returns the requests received, in order.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
+
+func (f *Fake) ChooseFileContext(ctx context.Context, opts explorer.Options) (io.ReadCloser, error) {
+	res, err := f.next(ctx, Request{Kind: ChooseFile, Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	return res.files[0].open(), nil
+}
+
+func (f *Fake) ChooseFilesContext(ctx context.Context, opts explorer.Options) ([]io.ReadCloser, error) {
+	res, err := f.next(ctx, Request{Kind: ChooseFiles, Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]io.ReadCloser, len(res.files))
+	for i, file := range res.files {
+		files[i] = file.open()
+	}
+	return files, nil
+}
+
+func (f *Fake) ChooseFolderContext(ctx context.Context, opts explorer.Options) (string, error) {
+	res, err := f.next(ctx, Request{Kind: ChooseFolder, Options: opts})
+	if err != nil {
+		return "", err
+	}
+	if res.folder == "" {
+		return "", fmt.Errorf("explorertest: result for %v has no folder", ChooseFolder)
+	}
+	return res.folder, nil
+}
+
+func (f *Fake) CreateFileContext(ctx context.Context, opts explorer.Options) (io.WriteCloser, error) {
+	res, err := f.next(ctx, Request{Kind: CreateFile, Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	return res.files[0].open(), nil
+}
+
+func (f *Fake) ReadFile(uri string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, Request{Kind: ReadFile, URI: uri})
+	data, ok := f.files[uri]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: uri, Err: fs.ErrNotExist}
+	}
+	file := NewFile(path.Base(uri), data)
+	return explorer.NewFile(file.name, uri, file), nil
+}
+
+func (f *Fake) add(r result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, r)
+}
+
+// next records req and returns the next result.
+func (f *Fake) next(ctx context.Context, req Request) (result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	req.Extensions = req.Options.Extensions()
+	f.requests = append(f.requests, req)
+	if err := ctx.Err(); err != nil {
+		return result{}, err
+	}
+	if len(f.results) == 0 {
+		return result{}, explorer.ErrUserDecline
+	}
+	res := f.results[0]
+	f.results = f.results[1:]
+	switch {
+	case res.err != nil:
+		return result{}, res.err
+	case req.Kind != ChooseFolder && len(res.files) == 0:
+		return result{}, fmt.Errorf("explorertest: result for %v has no files", req.Kind)
+	}
+	return res, nil
+}
+
+func (k Kind) String() string {
+	switch k {
+	case ChooseFile:
+		return "ChooseFile"
+	case ChooseFiles:
+		return "ChooseFiles"
+	case ChooseFolder:
+		return "ChooseFolder"
+	case CreateFile:
+		return "CreateFile"
+	case ReadFile:
+		return "ReadFile"
+	default:
+		return fmt.Sprintf("Kind(%d)", k)
+	}
+}
+
+// File is an in-memory file, returned by a Fake as an *explorer.File called
+// and identified by the name of the File.
+type File struct {
+	mu     sync.Mutex
+	name   string
+	data   []byte
+	off    int64
+	closed bool
+}
+
+// errClosed is returned by the methods of a closed File.
+var errClosed = errors.New("explorertest: file already closed")
+
+// NewFile returns a file called name, containing data.
+func NewFile(name string, data []byte) *File {
+	return &File{name: name, data: append([]byte(nil), data...)}
+}
+
+// Name returns the name of the file.
+func (f *File) Name() string { return f.name }
+
+// open returns the explorer.File returned by a Fake for f.
+func (f *File) open() *explorer.File {
+	return explorer.NewFile(f.name, f.name, f)
+}
+
+// Stat returns the information of the file, for the Size method of
+// explorer.File.
+func (f *File) Stat() (fs.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fileInfo{name: f.name, size: int64(len(f.data))}, nil
+}
+
+// Bytes returns the contents of the file, including the data written to it.
+func (f *File) Bytes() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]byte(nil), f.data...)
+}
+
+// Closed reports whether the file was closed.
+func (f *File) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, errClosed
+	}
+	if f.off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.data[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, errClosed
+	}
+	if end := f.off + int64(len(b)); end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	n := copy(f.data[f.off:], b)
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, errClosed
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += int64(len(f.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("explorertest: negative offset")
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return errClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// fileInfo implements fs.FileInfo for a File.
+type fileInfo struct {
+	name string
+	size int64
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return 0o666 }
+func (i fileInfo) ModTime() time.Time { return time.Time{} }
+func (i fileInfo) IsDir() bool        { return false }
+func (i fileInfo) Sys() interface{}   { return nil }
+
+var _ io.ReadWriteSeeker = (*File)(nil)
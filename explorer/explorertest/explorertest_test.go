@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorertest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"gioui.org/x/explorer"
+	"gioui.org/x/explorer/explorertest"
+)
+
+func TestFakeResponses(t *testing.T) {
+	fake := new(explorertest.Fake)
+	in := explorertest.NewFile("in.png", []byte("image"))
+	out := explorertest.NewFile("out.txt", nil)
+	errFailed := errors.New("failed")
+	fake.Respond(in)
+	fake.Respond(out)
+	fake.RespondFolder("/folder")
+	fake.Decline()
+	fake.Fail(errFailed)
+
+	ctx := context.Background()
+	r, err := fake.ChooseFileContext(ctx, explorer.Options{Filters: []explorer.Filter{{Extensions: []string{"png"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "image" {
+		t.Errorf("chosen file contains %q, %v, want %q", data, err, "image")
+	}
+	if err := r.Close(); err != nil || !in.Closed() {
+		t.Errorf("closing the chosen file returned %v, want the scripted file closed", err)
+	}
+
+	w, err := fake.CreateFileContext(ctx, explorer.Options{Name: "out.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "saved"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if got := string(out.Bytes()); got != "saved" {
+		t.Errorf("created file contains %q, want %q", got, "saved")
+	}
+
+	if folder, err := fake.ChooseFolderContext(ctx, explorer.Options{}); err != nil || folder != "/folder" {
+		t.Errorf("chose folder %q, %v, want /folder", folder, err)
+	}
+	if _, err := fake.ChooseFilesContext(ctx, explorer.Options{}); !errors.Is(err, explorer.ErrUserDecline) {
+		t.Errorf("declined dialog returned %v", err)
+	}
+	if _, err := fake.ChooseFileContext(ctx, explorer.Options{}); !errors.Is(err, errFailed) {
+		t.Errorf("failed dialog returned %v", err)
+	}
+	if _, err := fake.ChooseFileContext(ctx, explorer.Options{}); !errors.Is(err, explorer.ErrUserDecline) {
+		t.Errorf("dialog without a result returned %v, want ErrUserDecline", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	fake.Respond(in)
+	if _, err := fake.ChooseFileContext(cancelled, explorer.Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled dialog returned %v", err)
+	}
+}
+
+func TestFakeRequests(t *testing.T) {
+	fake := new(explorertest.Fake)
+	fake.Store("/data/a.txt", []byte("a"))
+	opts := explorer.Options{
+		Title:   "Open",
+		Filters: []explorer.Filter{{Name: "Images", Extensions: []string{"png", ".jpg"}}},
+	}
+	ctx := context.Background()
+	fake.ChooseFilesContext(ctx, opts)
+	fake.CreateFileContext(ctx, explorer.Options{Name: "b.txt"})
+	r, err := fake.ReadFile("/data/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if _, err := fake.ReadFile("/data/missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("reading a missing file returned %v, want fs.ErrNotExist", err)
+	}
+
+	reqs := fake.Requests()
+	var kinds []explorertest.Kind
+	for _, req := range reqs {
+		kinds = append(kinds, req.Kind)
+	}
+	want := []explorertest.Kind{explorertest.ChooseFiles, explorertest.CreateFile, explorertest.ReadFile, explorertest.ReadFile}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("recorded %v, want %v", kinds, want)
+	}
+	if got := reqs[0]; got.Options.Title != "Open" || !slices.Equal(got.Extensions, []string{".png", ".jpg"}) {
+		t.Errorf("recorded title %q and extensions %v, want Open and [.png .jpg]", got.Options.Title, got.Extensions)
+	}
+	if got := reqs[1].Options.Name; got != "b.txt" {
+		t.Errorf("recorded name %q, want b.txt", got)
+	}
+	if got := reqs[2].URI; got != "/data/a.txt" {
+		t.Errorf("recorded URI %q, want /data/a.txt", got)
+	}
+}
+
+func TestFakeFiles(t *testing.T) {
+	fake := new(explorertest.Fake)
+	fake.Respond(explorertest.NewFile("image.png", []byte("image")))
+	fake.Store("/data/a.png", []byte("a"))
+
+	r, err := fake.ChooseFileContext(context.Background(), explorer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stored, err := fake.ReadFile("/data/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stored.Close()
+
+	tests := []struct {
+		file                io.ReadCloser
+		name, uri, mimeType string
+		size                int64
+	}{
+		{r, "image.png", "image.png", "image/png", 5},
+		{stored, "a.png", "/data/a.png", "image/png", 1},
+	}
+	for _, test := range tests {
+		f, ok := test.file.(*explorer.File)
+		if !ok {
+			t.Errorf("fake returned a %T, want an *explorer.File", test.file)
+			continue
+		}
+		if f.Name() != test.name || f.URI() != test.uri || f.MIMEType() != test.mimeType || f.Size() != test.size {
+			t.Errorf("fake returned %q at %q of type %q and size %d, want %q at %q of type %q and size %d",
+				f.Name(), f.URI(), f.MIMEType(), f.Size(), test.name, test.uri, test.mimeType, test.size)
+		}
+	}
+}
+
+func TestFakeFallback(t *testing.T) {
+	fake := new(explorertest.Fake)
+	fake.RespondFolder("/folder")
+	e := explorer.NewExplorer(nil)
+	e.SetFallback(fake, explorer.FallbackAlways)
+	folder, err := e.ChooseFolderWithOptions(explorer.Options{Title: "Folder"})
+	if err != nil || folder != "/folder" {
+		t.Errorf("chose folder %q, %v, want /folder", folder, err)
+	}
+	if reqs := fake.Requests(); len(reqs) != 1 || reqs[0].Kind != explorertest.ChooseFolder || reqs[0].Options.Title != "Folder" {
+		t.Errorf("recorded %+v, want a ChooseFolder request", reqs)
+	}
+}
+
+func TestKindString(t *testing.T) {
+	if got := explorertest.ChooseFolder.String(); got != "ChooseFolder" {
+		t.Errorf("got %q, want ChooseFolder", got)
+	}
+	if got := explorertest.Kind(42).String(); got != "Kind(42)" {
+		t.Errorf("got %q for an invalid kind, want Kind(42)", got)
+	}
+}
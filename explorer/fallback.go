@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorer
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Fallback selects when an Explorer shows the dialogs configured by
+// SetFallback instead of the dialogs of the platform.
+type Fallback uint8
+
+const (
+	// FallbackNever never shows the fallback dialogs. It's the default.
+	FallbackNever Fallback = iota
+	// FallbackAuto shows the fallback dialogs when the dialogs of the
+	// platform are unavailable, such as when no xdg-desktop-portal is
+	// running.
+	FallbackAuto
+	// FallbackAlways shows the fallback dialogs instead of the dialogs of
+	// the platform.
+	FallbackAlways
+)
+
+// fallback holds the fallback dialogs of an Explorer.
+type fallback struct {
+	// mutex protects mode and dialogs, which are accessed by the goroutines
+	// showing dialogs.
+	mutex   sync.Mutex
+	mode    Fallback
+	dialogs Dialogs
+}
+
+// SetFallback configures e to show the dialogs of d according to mode, such
+// as the in-app file browser of package gioui.org/x/explorer/browser, which
+// can be used where the platform offers no dialogs, or no dialog for
+// multiple files or folders. A nil d disables the fallback.
+func (e *Explorer) SetFallback(d Dialogs, mode Fallback) {
+	if e == nil {
+		return
+	}
+	e.fallback.mutex.Lock()
+	defer e.fallback.mutex.Unlock()
+	e.fallback.dialogs = d
+	e.fallback.mode = mode
+}
+
+// withFallback runs work, which shows a dialog of the platform, or fallback,
+// which shows a dialog of d instead, according to the fallback mode of e.
+func withFallback[T any](ctx context.Context, e *Explorer, work func(ctx context.Context) (T, error), fallback func(ctx context.Context, d Dialogs) (T, error)) (T, error) {
+	f := &e.fallback
+	f.mutex.Lock()
+	mode, d := f.mode, f.dialogs
+	if d == nil {
+		mode = FallbackNever
+	}
+	f.mutex.Unlock()
+	switch mode {
+	case FallbackAlways:
+		return fallback(ctx, d)
+	case FallbackAuto:
+		v, err := work(ctx)
+		if errors.Is(err, ErrNotAvailable) {
+			return fallback(ctx, d)
+		}
+		return v, err
+	default:
+		return work(ctx)
+	}
+}
+
+func (e *Explorer) invalidate() {
+	if e.window != nil {
+		e.window.Invalidate()
+	}
+}
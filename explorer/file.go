@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build !android && !ios
+
+package explorer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// File is a file chosen or created by an Explorer.
+type File struct {
+	// file is the underlying file. It's an *os.File, except in browsers and
+	// for files returned by NewFile.
+	file     io.Closer
+	name     string
+	uri      string
+	mimeType string
+	size     int64
+	modTime  time.Time
+}
+
+// openFile opens the file at path for reading.
+func openFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return newOSFile(f), nil
+}
+
+// createFile creates or truncates the file at path.
+func createFile(path string) (*File, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return newOSFile(f), nil
+}
+
+// createFileOptions creates the file at path, atomically if opts.Atomic is
+// set.
+func createFileOptions(path string, opts Options) (*File, error) {
+	if !opts.Atomic {
+		return createFile(path)
+	}
+	// The temporary file has the permissions of the replaced file, or those
+	// of a new file, which are 0o666 less the umask.
+	perm, replace := fs.FileMode(0o666), false
+	if info, err := os.Stat(path); err == nil {
+		perm, replace = info.Mode().Perm(), true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	dir, base := filepath.Split(path)
+	tmp, err := createTemp(dir, "."+base+".", ".tmp", perm)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotAtomic, err)
+	}
+	if replace {
+		// Restore the permissions cleared by the umask.
+		if err := tmp.Chmod(perm); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return nil, err
+		}
+	}
+	return &File{
+		file:     &atomicFile{File: tmp, path: path},
+		name:     base,
+		uri:      path,
+		mimeType: mimeType(base),
+	}, nil
+}
+
+// createTemp creates a new file in dir, named prefix followed by a random
+// number and suffix, with the permissions perm less the umask. Unlike
+// os.CreateTemp, it doesn't restrict the permissions to the owner.
+func createTemp(dir, prefix, suffix string, perm fs.FileMode) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) && try < 100 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// atomicFile is a temporary file that replaces the file at path when it's
+// closed, so that the file is never partially written.
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+func (f *atomicFile) Close() error {
+	err := f.File.Sync()
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.File.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.File.Name())
+	}
+	return err
+}
+
+func newCustomFile(name, uri string, f io.Closer) *File {
+	return &File{
+		file:     f,
+		name:     name,
+		uri:      uri,
+		mimeType: mimeType(name),
+	}
+}
+
+func newOSFile(f *os.File) *File {
+	name := filepath.Base(f.Name())
+	return &File{
+		file:     f,
+		name:     name,
+		uri:      f.Name(),
+		mimeType: mimeType(name),
+	}
+}
+
+func (f *File) Read(b []byte) (n int, err error) {
+	if f == nil {
+		return 0, ErrNotAvailable
+	}
+	return readCustom(f.file, b)
+}
+
+func (f *File) Write(b []byte) (n int, err error) {
+	if f == nil {
+		return 0, ErrNotAvailable
+	}
+	return writeCustom(f.file, b)
+}
+
+// Seek implements io.Seeker. It returns ErrNotAvailable where the file can't
+// seek.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f == nil {
+		return 0, ErrNotAvailable
+	}
+	return seekCustom(f.file, offset, whence)
+}
+
+func (f *File) Close() error {
+	if f == nil || f.file == nil {
+		return ErrNotAvailable
+	}
+	return f.file.Close()
+}
+
+// Name returns the base name of the file.
+func (f *File) Name() string { return f.name }
+
+// Size returns the size of the file in bytes.
+func (f *File) Size() int64 {
+	if info, ok := f.stat(); ok {
+		return info.Size()
+	}
+	return f.size
+}
+
+// URI returns the identifier of the file for Explorer.ReadFile, which is its
+// path on desktop platforms. It's empty in browsers, where files can't be
+// opened again.
+func (f *File) URI() string { return f.uri }
+
+// MIMEType returns the media type of the file, such as "image/png", or the
+// empty string if it's unknown.
+func (f *File) MIMEType() string { return f.mimeType }
+
+// ModTime returns the modification time of the file, or the zero time if it's
+// unknown.
+func (f *File) ModTime() time.Time {
+	if info, ok := f.stat(); ok {
+		return info.ModTime()
+	}
+	return f.modTime
+}
+
+// stat returns the current information of the file, if it's an *os.File, an
+// atomically written file, or a file passed to NewFile with a Stat method.
+func (f *File) stat() (os.FileInfo, bool) {
+	return statCustom(f.file)
+}
+
+var _ io.ReadWriteSeeker = (*File)(nil)
@@ -3,6 +3,7 @@ package explorer
 import (
 	"errors"
 	"io"
+	"time"
 
 	"gioui.org/app"
 	"git.wow.st/gmp/jni"
@@ -28,6 +29,14 @@ type File struct {
 	sharedBuffer    jni.Object
 	sharedBufferLen int
 	isClosed        bool
+
+	// custom is the underlying file of a file returned by NewFile, which
+	// isn't a Java stream.
+	custom io.Closer
+}
+
+func newCustomFile(name, uri string, f io.Closer) *File {
+	return &File{name: name, uri: uri, custom: f}
 }
 
 func newFile(env jni.Env, name string, size int64, uri string, stream jni.Object) (*File, error) {
@@ -60,13 +69,53 @@ func newFile(env jni.Env, name string, size int64, uri string, stream jni.Object
 
 }
 
+// openFile opens the file at path for reading. Files are chosen through
+// content URIs on Android, so files can't be opened by their path.
+func openFile(path string) (*File, error) {
+	return nil, ErrNotAvailable
+}
+
+// createFile creates the file at path. See openFile.
+func createFile(path string) (*File, error) {
+	return nil, ErrNotAvailable
+}
+
+// createFileOptions creates the file at path. See openFile.
+func createFileOptions(path string, _ Options) (*File, error) {
+	return createFile(path)
+}
+
+// Name returns the display name of the file.
 func (f *File) Name() string { return f.name }
 
-func (f *File) Size() int64 { return f.size }
+// Size returns the size of the file in bytes, as reported when it was chosen.
+func (f *File) Size() int64 {
+	if info, ok := statCustom(f.custom); ok {
+		return info.Size()
+	}
+	return f.size
+}
 
+// URI returns the content URI of the file, for Explorer.ReadFile.
 func (f *File) URI() string { return f.uri }
 
+// MIMEType returns the media type of the file, such as "image/png", or the
+// empty string if it's unknown.
+func (f *File) MIMEType() string { return mimeType(f.name) }
+
+// ModTime returns the zero time, since the modification time of files isn't
+// reported on Android, except for files returned by NewFile.
+func (f *File) ModTime() time.Time {
+	if info, ok := statCustom(f.custom); ok {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
 func (f *File) Read(b []byte) (n int, err error) {
+	if f != nil && f.custom != nil {
+		return readCustom(f.custom, b)
+	}
 	if f == nil || f.isClosed {
 		return 0, io.ErrClosedPipe
 	}
@@ -101,6 +150,9 @@ func (f *File) Read(b []byte) (n int, err error) {
 }
 
 func (f *File) Write(b []byte) (n int, err error) {
+	if f != nil && f.custom != nil {
+		return writeCustom(f.custom, b)
+	}
 	if f == nil || f.isClosed {
 		return 0, io.ErrClosedPipe
 	}
@@ -125,6 +177,9 @@ func (f *File) Write(b []byte) (n int, err error) {
 }
 
 func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.custom != nil {
+		return seekCustom(f.custom, offset, whence)
+	}
 	if whence == io.SeekCurrent || whence == io.SeekEnd {
 		return 0, ErrNotAvailable
 	}
@@ -145,6 +200,9 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (f *File) Close() error {
+	if f != nil && f.custom != nil {
+		return f.custom.Close()
+	}
 	if f == nil || f.isClosed {
 		return io.ErrClosedPipe
 	}
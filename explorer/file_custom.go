@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorer
+
+import (
+	"io"
+	"io/fs"
+)
+
+// NewFile returns a File called name, with the URI uri, that reads, writes,
+// seeks and closes f, where f implements the io interfaces for them. Its size
+// and modification time are those returned by the Stat method of f, if any.
+//
+// NewFile is meant for implementations of Dialogs other than Explorer, such as
+// fakes for tests, so that they return files of the same type.
+func NewFile(name, uri string, f io.Closer) *File {
+	return newCustomFile(name, uri, f)
+}
+
+// The following functions implement the methods of files returned by
+// NewFile.
+
+func readCustom(f io.Closer, b []byte) (int, error) {
+	r, ok := f.(io.Reader)
+	if !ok {
+		return 0, ErrNotAvailable
+	}
+	return r.Read(b)
+}
+
+func writeCustom(f io.Closer, b []byte) (int, error) {
+	w, ok := f.(io.Writer)
+	if !ok {
+		return 0, ErrNotAvailable
+	}
+	return w.Write(b)
+}
+
+func seekCustom(f io.Closer, offset int64, whence int) (int64, error) {
+	s, ok := f.(io.Seeker)
+	if !ok {
+		return 0, ErrNotAvailable
+	}
+	return s.Seek(offset, whence)
+}
+
+func statCustom(f io.Closer) (fs.FileInfo, bool) {
+	s, ok := f.(interface{ Stat() (fs.FileInfo, error) })
+	if !ok {
+		return nil, false
+	}
+	info, err := s.Stat()
+	return info, err == nil
+}
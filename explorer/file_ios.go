@@ -1,3 +1,8 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build ios
+// +build ios
+
 package explorer
 
 /*
@@ -19,22 +24,38 @@ extern bool fileClose(CFTypeRef file);
 extern char* getError(CFTypeRef file);
 extern const char* getURL(CFTypeRef url_ref);
 extern unsigned long long getSize(CFTypeRef url_ref);
+extern double getModTime(CFTypeRef url_ref);
+extern CFTypeRef createURLFromPath(const char* path);
+extern void releaseURL(CFTypeRef url);
 
 */
 import "C"
 import (
 	"errors"
 	"io"
+	"math"
 	"net/url"
+	"os"
 	"path/filepath"
+	"time"
 	"unsafe"
 )
 
 type File struct {
-	file   C.CFTypeRef
-	size   uint64
-	url    string
-	closed bool
+	file    C.CFTypeRef
+	size    uint64
+	url     string
+	modTime time.Time
+	closed  bool
+
+	// custom is the underlying file of a file returned by NewFile, called
+	// name.
+	custom io.Closer
+	name   string
+}
+
+func newCustomFile(name, uri string, f io.Closer) *File {
+	return &File{url: uri, custom: f, name: name}
 }
 
 func newFile(url C.CFTypeRef) (*File, error) {
@@ -53,10 +74,45 @@ func newFile(url C.CFTypeRef) (*File, error) {
 		size: size,
 		url:  urlStr,
 	}
+	// The modification time is in seconds since the epoch, or NaN if it's
+	// unknown.
+	if t := float64(C.getModTime(url)); !math.IsNaN(t) {
+		sec, frac := math.Modf(t)
+		ret.modTime = time.Unix(int64(sec), int64(frac*1e9))
+	}
 	return ret, nil
 }
 
+// openFile opens the file at path.
+func openFile(path string) (*File, error) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	u := C.createURLFromPath(cpath)
+	defer C.releaseURL(u)
+	return newFile(u)
+}
+
+// createFile creates or truncates the file at path.
+func createFile(path string) (*File, error) {
+	// NSFileHandle only opens existing files.
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return openFile(path)
+}
+
+// createFileOptions creates or truncates the file at path. Files are
+// written in place, since NSFileHandle writes files directly.
+func createFileOptions(path string, _ Options) (*File, error) {
+	return createFile(path)
+}
+
 func (f *File) Read(b []byte) (n int, err error) {
+	if f.custom != nil {
+		return readCustom(f.custom, b)
+	}
 	if f.file == 0 || f.closed {
 		return 0, io.ErrClosedPipe
 	}
@@ -74,6 +130,9 @@ func (f *File) Read(b []byte) (n int, err error) {
 }
 
 func (f *File) Write(b []byte) (n int, err error) {
+	if f.custom != nil {
+		return writeCustom(f.custom, b)
+	}
 	if f.file == 0 || f.closed {
 		return 0, io.ErrClosedPipe
 	}
@@ -91,6 +150,9 @@ func (f *File) Write(b []byte) (n int, err error) {
 }
 
 func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.custom != nil {
+		return seekCustom(f.custom, offset, whence)
+	}
 	if f.file == 0 || f.closed {
 		return 0, io.ErrClosedPipe
 	}
@@ -98,13 +160,40 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 	return int64(C.fileSeek(f.file, C.uint64_t(offset), C.int(whence))), nil
 }
 
+// Name returns the base name of the file.
 func (f *File) Name() string {
+	if f.custom != nil {
+		return f.name
+	}
 	return filepath.Base(f.URI())
 }
 
-func (f *File) Size() int64 { return int64(f.size) }
+// Size returns the size of the file in bytes, as reported when it was opened.
+func (f *File) Size() int64 {
+	if info, ok := statCustom(f.custom); ok {
+		return info.Size()
+	}
+	return int64(f.size)
+}
 
+// MIMEType returns the media type of the file, such as "image/png", or the
+// empty string if it's unknown.
+func (f *File) MIMEType() string { return mimeType(f.Name()) }
+
+// ModTime returns the modification time of the file, or the zero time if it's
+// unknown.
+func (f *File) ModTime() time.Time {
+	if info, ok := statCustom(f.custom); ok {
+		return info.ModTime()
+	}
+	return f.modTime
+}
+
+// URI returns the path of the file, for Explorer.ReadFile.
 func (f *File) URI() string {
+	if f.custom != nil {
+		return f.url
+	}
 	parsed, err := url.Parse(f.url)
 	if err != nil {
 		return ""
@@ -114,6 +203,9 @@ func (f *File) URI() string {
 }
 
 func (f *File) Close() error {
+	if f.custom != nil {
+		return f.custom.Close()
+	}
 	if ok := bool(C.fileClose(f.file)); !ok {
 		return getError(f.file)
 	}
@@ -138,6 +230,7 @@ func getError(file C.CFTypeRef) error {
 func file_darwin() {}
 
 var (
+	_ io.ReadWriteSeeker = (*File)(nil)
 	_ io.ReadWriteCloser = (*File)(nil)
 	_ io.ReadCloser      = (*File)(nil)
 	_ io.WriteCloser     = (*File)(nil)
@@ -1,3 +1,8 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build ios
+// +build ios
+
 #include "_cgo_export.h"
 
 @implementation explorer_file
@@ -130,4 +135,15 @@ unsigned long long getSize(CFTypeRef url_ref) {
     }
 
     return [fileSize unsignedLongLongValue];
-}
\ No newline at end of file
+}
+
+double getModTime(CFTypeRef url_ref) {
+    NSURL *url = (__bridge NSURL *)url_ref;
+    NSDate *date = nil;
+
+    if (![url getResourceValue:&date forKey:NSURLContentModificationDateKey error:nil] || date == nil) {
+        return NAN;
+    }
+
+    return [date timeIntervalSince1970];
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build !android && !ios
+
+package explorer
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// dirNames returns the names of the files in dir.
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestCreateAtomicReplace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.txt")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	const perm = 0o640
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := createFileOptions(path, Options{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("file contains %q before Close, want it unchanged", data)
+	}
+	if names := dirNames(t, dir); len(names) != 2 {
+		t.Errorf("folder contains %v before Close, want the file and a temporary file", names)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file contains %q after Close, want %q", data, "new")
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Errorf("folder contains %v after Close, want only the file", names)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); runtime.GOOS != "windows" && got != perm {
+		t.Errorf("replaced file has permissions %v, want %v", got, fs.FileMode(perm))
+	}
+}
+
+func TestCreateAtomicNew(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new.txt")
+	f, err := createFileOptions(path, Options{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("file exists before Close: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Files created by os.Create have the permissions 0o666 less the umask.
+	plain, err := os.Create(filepath.Join(t.TempDir(), "plain.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain.Close()
+	want, err := os.Stat(plain.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), want.Mode().Perm(); got != want {
+		t.Errorf("new file has permissions %v, want %v", got, want)
+	}
+}
+
+func TestCreateAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.txt")
+	f, err := createFileOptions(path, Options{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replacing a folder that isn't empty fails.
+	if err := os.MkdirAll(filepath.Join(path, "child"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err == nil {
+		t.Error("expected an error replacing a folder")
+	}
+	if names := dirNames(t, dir); len(names) != 1 || names[0] != "doc.txt" {
+		t.Errorf("folder contains %v after the failure, want the temporary file removed", names)
+	}
+
+	_, err = createFileOptions(filepath.Join(dir, "missing", "doc.txt"), Options{Atomic: true})
+	if !errors.Is(err, ErrNotAtomic) {
+		t.Errorf("creating a file without a temporary file returned %v, want ErrNotAtomic", err)
+	}
+}
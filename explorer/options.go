@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorer
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// Filter is a named group of file types that a dialog offers to the user.
+type Filter struct {
+	// Name describes the file types, such as "Images". If empty, the file
+	// types are listed instead.
+	Name string
+	// Extensions are file extensions such as ".png" or ".jpg".
+	Extensions []string
+	// MIMETypes are media types such as "image/png".
+	MIMETypes []string
+}
+
+// Options configures a dialog. Platforms ignore the options they don't
+// support.
+type Options struct {
+	// Title of the dialog. If empty, a default title is used.
+	Title string
+	// AcceptLabel is the label of the button that accepts the selection.
+	AcceptLabel string
+	// Filters restrict the files that can be chosen. Platforms that can't
+	// offer separate filters accept the file types of every filter.
+	Filters []Filter
+	// DefaultFilter is the index in Filters of the filter that is selected
+	// when the dialog opens.
+	DefaultFilter int
+	// Folder is the path of the folder that the dialog opens in.
+	Folder string
+	// Name is the suggested name of a created file.
+	Name string
+	// Atomic makes a created file be written to a temporary file in the
+	// same folder, which replaces the chosen file when it's closed, so that
+	// the file is never partially written. Creating the file fails with
+	// ErrNotAtomic where the temporary file can't be created, such as in
+	// sandboxes granting access to the chosen file only; the dialog can then
+	// be shown again without Atomic. Atomic is ignored on Android, iOS and in
+	// browsers, where files are written through the platform.
+	Atomic bool
+}
+
+// extensionOptions returns the options of a dialog that accepts files with
+// the given extensions.
+func extensionOptions(extensions []string) Options {
+	if len(extensions) == 0 {
+		return Options{}
+	}
+	return Options{Filters: []Filter{{Extensions: extensions}}}
+}
+
+// extensions returns the extensions of the filter, with a leading dot,
+// including the extensions known for its MIME types.
+func (f Filter) extensions() []string {
+	var exts []string
+	for _, ext := range f.Extensions {
+		exts = appendUnique(exts, normalizeExtension(ext))
+	}
+	for _, mt := range f.MIMETypes {
+		known, _ := mime.ExtensionsByType(mt)
+		for _, ext := range known {
+			exts = appendUnique(exts, ext)
+		}
+	}
+	return exts
+}
+
+// mimeTypes returns the MIME types of the filter, including the types of
+// its extensions. Extensions of unknown type are omitted.
+func (f Filter) mimeTypes() []string {
+	var types []string
+	for _, mt := range f.MIMETypes {
+		types = appendUnique(types, mt)
+	}
+	for _, ext := range f.Extensions {
+		if mt := mime.TypeByExtension(normalizeExtension(ext)); mt != "" {
+			types = appendUnique(types, mt)
+		}
+	}
+	return types
+}
+
+// Extensions returns the extensions accepted by any of the filters, with a
+// leading dot, including the extensions known for their MIME types.
+func (o Options) Extensions() []string {
+	var exts []string
+	for _, f := range o.Filters {
+		for _, ext := range f.extensions() {
+			exts = appendUnique(exts, ext)
+		}
+	}
+	return exts
+}
+
+// mimeTypes returns the MIME types accepted by any of the filters.
+func (o Options) mimeTypes() []string {
+	var types []string
+	for _, f := range o.Filters {
+		for _, mt := range f.mimeTypes() {
+			types = appendUnique(types, mt)
+		}
+	}
+	return types
+}
+
+// normalizeExtension returns ext, such as "png" or "*.png", in the form
+// ".png".
+func normalizeExtension(ext string) string {
+	ext = strings.TrimPrefix(ext, "*")
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// mimeType returns the media type of the file with the given name, without
+// parameters, or the empty string if the type of its extension is unknown.
+func mimeType(name string) string {
+	t := mime.TypeByExtension(filepath.Ext(name))
+	if mt, _, err := mime.ParseMediaType(t); err == nil {
+		return mt
+	}
+	return t
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"gioui.org/app"
+)
+
+// RecentFile is a file recorded by RecentFiles.
+type RecentFile struct {
+	// URI identifies the file for Explorer.ReadFile.
+	URI string `json:"uri"`
+	// Name is the display name of the file.
+	Name string `json:"name"`
+	// Used is the time the file was last added or opened.
+	Used time.Time `json:"used"`
+}
+
+// RecentFiles is a list of recently used files, most recent first, which
+// is saved in a JSON file in the data directory of the app.
+//
+// Where the platform grants access to chosen files only temporarily, adding
+// a file asks to keep access to it: Android persists the permission of
+// content URIs, and sandboxed apps on Linux export the file through the
+// Documents portal. Files may still become inaccessible, such as when
+// they're deleted, which Accessible reports.
+type RecentFiles struct {
+	// Max is the number of files kept. Older files are removed when
+	// files are added.
+	Max int
+
+	explorer *Explorer
+	path     string
+
+	mu    sync.Mutex
+	files []RecentFile
+}
+
+// NewRecentFiles returns the recent files saved in the file called name,
+// in the directory of the app within app.DataDir. Files are reopened with
+// e, which must not be nil.
+func NewRecentFiles(e *Explorer, name string) (*RecentFiles, error) {
+	dir, err := app.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	r := &RecentFiles{
+		Max:      20,
+		explorer: e,
+		path:     filepath.Join(dir, app.ID, name),
+	}
+	data, err := os.ReadFile(r.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return r, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.files); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Files returns the recent files, most recent first.
+func (r *RecentFiles) Files() []RecentFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.files)
+}
+
+// Add records f, which was chosen or created by the Explorer, as the most
+// recent file, and asks the platform to keep access to it. The URI of the
+// recorded file may differ from f.URI().
+func (r *RecentFiles) Add(f *File) error {
+	if f.URI() == "" {
+		// Browsers don't identify files.
+		return ErrNotAvailable
+	}
+	uri, err := r.explorer.keepAccess(f.URI())
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.use(RecentFile{URI: uri, Name: f.Name()})
+	return r.save()
+}
+
+// Open reopens the recent file with the URI through Explorer.ReadFile, and
+// makes it the most recent file.
+func (r *RecentFiles) Open(uri string) (io.ReadCloser, error) {
+	file, err := r.explorer.ReadFile(uri)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.index(uri); i >= 0 {
+		r.use(r.files[i])
+		if err := r.save(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// Accessible reports whether the file with the URI can still be opened. It
+// opens the file, and may block like Explorer.ReadFile.
+func (r *RecentFiles) Accessible(uri string) bool {
+	file, err := r.explorer.ReadFile(uri)
+	if err != nil {
+		return false
+	}
+	file.Close()
+	return true
+}
+
+// Remove removes the file with the URI.
+func (r *RecentFiles) Remove(uri string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(uri)
+	if i < 0 {
+		return nil
+	}
+	r.files = slices.Delete(r.files, i, i+1)
+	return r.save()
+}
+
+// Prune removes the files that are no longer accessible.
+func (r *RecentFiles) Prune() error {
+	// Check the files without the lock, since opening them may block.
+	var gone []string
+	for _, f := range r.Files() {
+		if !r.Accessible(f.URI) {
+			gone = append(gone, f.URI)
+		}
+	}
+	if len(gone) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = slices.DeleteFunc(r.files, func(f RecentFile) bool {
+		return slices.Contains(gone, f.URI)
+	})
+	return r.save()
+}
+
+// use makes f the most recent file.
+func (r *RecentFiles) use(f RecentFile) {
+	if i := r.index(f.URI); i >= 0 {
+		r.files = slices.Delete(r.files, i, i+1)
+	}
+	f.Used = time.Now()
+	r.files = slices.Insert(r.files, 0, f)
+	if r.Max > 0 && len(r.files) > r.Max {
+		r.files = r.files[:r.Max]
+	}
+}
+
+func (r *RecentFiles) index(uri string) int {
+	return slices.IndexFunc(r.files, func(f RecentFile) bool {
+		return f.URI == uri
+	})
+}
+
+// save writes the files, replacing the saved file only once they're written.
+func (r *RecentFiles) save() error {
+	data, err := json.MarshalIndent(r.files, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
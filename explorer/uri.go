@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package explorer
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// uriPath returns the path of the file URI.
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("explorer: unsupported URI %q", uri)
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// Remove the slash before the drive letter of "/C:/dir".
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// localPath returns the absolute path of uri if it's a path or a file URI.
+func localPath(uri string) (string, bool) {
+	path := uri
+	// Windows paths such as "C:\dir" parse as URIs.
+	if !filepath.IsAbs(uri) {
+		u, err := url.Parse(uri)
+		switch {
+		case err == nil && u.Scheme == "file":
+			if path, err = uriPath(uri); err != nil {
+				return "", false
+			}
+		case err == nil && u.Scheme != "":
+			return "", false
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path, true
+}
+
+// fileURI returns the file URI of the absolute path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths start with a drive letter.
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
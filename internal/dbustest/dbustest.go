@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package dbustest starts private D-Bus session buses for tests.
+package dbustest
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// StartBus starts a private session bus for the test, and points
+// DBUS_SESSION_BUS_ADDRESS to it. The test is skipped if dbus-daemon isn't
+// installed. The bus is stopped when the test ends.
+func StartBus(t *testing.T) {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "bus")
+	config := `<busconfig>
+	<type>session</type>
+	<listen>unix:path=` + socket + `</listen>
+	<auth>EXTERNAL</auth>
+	<policy context="default">
+		<allow send_destination="*" eavesdrop="true"/>
+		<allow eavesdrop="true"/>
+		<allow own="*"/>
+	</policy>
+</busconfig>`
+	configPath := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--nofork", "--print-address", "--config-file="+configPath)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	// The address is printed once the bus accepts connections.
+	if _, err := bufio.NewReader(out).ReadString('\n'); err != nil {
+		t.Fatalf("dbus-daemon didn't start: %v", err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+socket)
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Action is a button of a notification.
+type Action struct {
+	// ID identifies the action in the events of its notification.
+	ID string
+	// Label is the text of the button, such as "Reply".
+	Label string
+}
+
+// EventKind is the kind of an Event.
+type EventKind uint8
+
+const (
+	// Clicked reports a click on the notification, outside its buttons.
+	Clicked EventKind = iota
+	// ActionInvoked reports a click on an action button.
+	ActionInvoked
+	// Closed reports that the notification was closed.
+	Closed
+)
+
+// CloseReason describes why a notification was closed.
+type CloseReason uint8
+
+const (
+	// ReasonUnknown means the platform didn't report why the notification
+	// was closed.
+	ReasonUnknown CloseReason = iota
+	// ReasonExpired means the notification timed out.
+	ReasonExpired
+	// ReasonDismissed means the user dismissed the notification.
+	ReasonDismissed
+	// ReasonCancelled means the notification was closed by Cancel.
+	ReasonCancelled
+)
+
+// Event reports an interaction with a notification created by an
+// ActionNotifier.
+type Event struct {
+	// Notification is the notification returned by
+	// CreateActionNotification.
+	Notification Notification
+	Kind         EventKind
+	// Action is the ID of the invoked action, for ActionInvoked events.
+	Action string
+	// Reason is the reason of Closed events.
+	Reason CloseReason
+}
+
+// eventBufferSize is the capacity of the channels returned by
+// ActionNotifier.Events.
+const eventBufferSize = 32
+
+// sendEvent sends e to events, dropping it if the channel is full, so that
+// an unread channel doesn't block the notifier.
+func sendEvent(events chan<- Event, e Event) {
+	select {
+	case events <- e:
+	default:
+	}
+}
+
+// EventQueue receives the events of an ActionNotifier in the background,
+// and invalidates a window when they arrive, so that the window can handle
+// them while drawing its frames:
+//
+//	queue := notify.NewEventQueue(ctx, notifier, window)
+//
+//	// In every frame:
+//	for {
+//		e, ok := queue.Next()
+//		if !ok {
+//			break
+//		}
+//		// Handle e.
+//	}
+type EventQueue struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewEventQueue returns a queue receiving the events of n, and invalidating
+// w, usually an *app.Window, when they arrive. The queue stops receiving
+// events once ctx is done, such as when the window is closed.
+func NewEventQueue(ctx context.Context, n ActionNotifier, w interface{ Invalidate() }) *EventQueue {
+	q := new(EventQueue)
+	events := n.Events()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-events:
+				q.mu.Lock()
+				q.events = append(q.events, e)
+				q.mu.Unlock()
+				w.Invalidate()
+			}
+		}
+	}()
+	return q
+}
+
+// Next returns the next event, if any.
+func (q *EventQueue) Next() (Event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) == 0 {
+		return Event{}, false
+	}
+	e := q.events[0]
+	q.events = q.events[1:]
+	return e, true
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeNotifier is an ActionNotifier whose events are sent by the test.
+type fakeNotifier struct {
+	events chan Event
+}
+
+func (f *fakeNotifier) CreateNotification(title, text string) (Notification, error) {
+	return nil, nil
+}
+
+func (f *fakeNotifier) CreateActionNotification(title, text string, actions ...Action) (Notification, error) {
+	return nil, nil
+}
+
+func (f *fakeNotifier) Events() <-chan Event {
+	return f.events
+}
+
+// invalidations counts the calls to Invalidate.
+type invalidations chan struct{}
+
+func (i invalidations) Invalidate() {
+	i <- struct{}{}
+}
+
+func TestEventQueue(t *testing.T) {
+	n := &fakeNotifier{events: make(chan Event)}
+	w := make(invalidations, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewEventQueue(ctx, n, w)
+
+	n.events <- Event{Kind: ActionInvoked, Action: "reply"}
+	<-w
+	if e, ok := q.Next(); !ok || e.Kind != ActionInvoked || e.Action != "reply" {
+		t.Errorf("queue returned %+v, %v, want the invoked action", e, ok)
+	}
+	if _, ok := q.Next(); ok {
+		t.Error("queue returned an event twice")
+	}
+
+	// Once ctx is done, the queue stops receiving events, although it may
+	// still receive those sent before it noticed.
+	cancel()
+	for i := 0; i < 100; i++ {
+		select {
+		case n.events <- Event{Kind: Clicked}:
+			<-w
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+	t.Error("queue kept receiving events after ctx was done")
+}
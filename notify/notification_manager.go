@@ -39,6 +39,23 @@ type OngoingNotifier interface {
 	CreateOngoingNotification(title, text string) (Notification, error)
 }
 
+// ActionNotifier is a notifier that can display notifications with action
+// buttons, and report the interactions with them. Some platforms (currently
+// Linux and Windows) will implement this optional interface.
+type ActionNotifier interface {
+	Notifier
+	// CreateActionNotification creates a notification with a button for
+	// each action. Clicks on the notification and its buttons are reported
+	// by Events, like the closing of the notification where the platform
+	// reports it.
+	CreateActionNotification(title, text string, actions ...Action) (Notification, error)
+	// Events returns the channel receiving the events of the notifications
+	// created by CreateActionNotification. Events are dropped while the
+	// channel is full, so it must be received from continuously, such as
+	// by an EventQueue.
+	Events() <-chan Event
+}
+
 // NewNotifier creates a new Manager tailored to the current operating system.
 func NewNotifier() (Notifier, error) {
 	return newNotifier()
@@ -70,3 +87,19 @@ func Push(title, text string) (Notification, error) {
 	}
 	return impl.CreateNotification(title, text)
 }
+
+// PushWithOptions pushes a notification configured by opts to the OS.
+func PushWithOptions(title, text string, opts Options) (Notification, error) {
+	implLock.Lock()
+	defer implLock.Unlock()
+	if impl == nil && implErr == nil {
+		impl, implErr = newNotifier()
+	}
+	if implErr != nil {
+		return nil, implErr
+	}
+	if n, ok := impl.(OptionsNotifier); ok {
+		return n.CreateNotificationWithOptions(title, text, opts)
+	}
+	return impl.CreateNotification(title, text)
+}
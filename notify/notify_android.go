@@ -11,7 +11,7 @@ type androidNotifier struct {
 	channel *android.NotificationChannel
 }
 
-var _ Notifier = (*androidNotifier)(nil)
+var _ OptionsNotifier = (*androidNotifier)(nil)
 
 func newNotifier() (Notifier, error) {
 	channel, err := android.NewChannel(android.ImportanceDefault, "DEFAULT", "niotify", "background notifications")
@@ -27,6 +27,12 @@ func (a *androidNotifier) CreateNotification(title, text string) (Notification,
 	return a.createNotification(title, text, false)
 }
 
+// CreateNotificationWithOptions is like CreateNotification. The options are
+// ignored, since the importance of notifications is set by their channel.
+func (a *androidNotifier) CreateNotificationWithOptions(title, text string, _ Options) (Notification, error) {
+	return a.CreateNotification(title, text)
+}
+
 func (a *androidNotifier) createNotification(title, text string, ongoing bool) (Notification, error) {
 	notification, err := a.channel.Send(title, text, ongoing)
 	if err != nil {
@@ -11,7 +11,7 @@ type darwinNotifier struct {
 	channel macos.NotificationChannel
 }
 
-var _ Notifier = (*darwinNotifier)(nil)
+var _ OptionsNotifier = (*darwinNotifier)(nil)
 
 func newNotifier() (Notifier, error) {
 	c := macos.NewNotificationChannel("Gio App")
@@ -26,3 +26,9 @@ func (a *darwinNotifier) CreateNotification(title, text string) (Notification, e
 	}
 	return notification, nil
 }
+
+// CreateNotificationWithOptions is like CreateNotification. The options are
+// ignored.
+func (a *darwinNotifier) CreateNotificationWithOptions(title, text string, _ Options) (Notification, error) {
+	return a.CreateNotification(title, text)
+}
@@ -5,29 +5,62 @@ package notify
 
 import (
 	"fmt"
+	"image"
+	"image/draw"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/esiqveland/notify"
 	dbus "github.com/godbus/dbus/v5"
 )
 
+// defaultAction is the key of the action invoked by clicking a notification.
+const defaultAction = "default"
+
 type dbusNotifier struct {
 	notify.Notifier
+	events chan Event
+
+	mu sync.Mutex
+	// active are the action notifications that weren't closed yet.
+	active map[uint32]*dbusNotification
 }
 
-var _ Notifier = (*dbusNotifier)(nil)
+var (
+	_ ActionNotifier  = (*dbusNotifier)(nil)
+	_ OptionsNotifier = (*dbusNotifier)(nil)
+)
 
 func newNotifier() (Notifier, error) {
 	conn, err := dbus.SessionBus()
 	if err != nil {
 		return nil, fmt.Errorf("failed connecting to dbus: %w", err)
 	}
-	notifier, err := notify.New(conn)
+	l, err := newDBusNotifier(conn)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// newDBusNotifier returns a notifier showing notifications through the
+// notification server of conn.
+func newDBusNotifier(conn *dbus.Conn) (*dbusNotifier, error) {
+	l := &dbusNotifier{
+		events: make(chan Event, eventBufferSize),
+		active: make(map[uint32]*dbusNotification),
+	}
+	notifier, err := notify.New(conn,
+		notify.WithOnAction(l.onAction),
+		notify.WithOnClosed(l.onClosed),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating notifier: %w", err)
 	}
-	return &dbusNotifier{
-		Notifier: notifier,
-	}, nil
+	l.Notifier = notifier
+	return l, nil
 }
 
 type dbusNotification struct {
@@ -51,6 +84,173 @@ func (l *dbusNotifier) CreateNotification(title, text string) (Notification, err
 	}, nil
 }
 
+// CreateNotificationWithOptions creates a notification configured by every
+// option. Like those of CreateActionNotification, the interactions with
+// notifications with actions are reported by Events.
+func (l *dbusNotifier) CreateNotificationWithOptions(title, text string, opts Options) (Notification, error) {
+	return l.send(notificationWithOptions(title, text, opts), len(opts.Actions) > 0)
+}
+
+// notificationWithOptions maps opts to the parameters and hints of a
+// notification.
+func notificationWithOptions(title, text string, opts Options) notify.Notification {
+	note := notify.Notification{
+		AppName: opts.AppName,
+		AppIcon: opts.IconPath,
+		Summary: title,
+		Body:    text,
+		Hints:   make(map[string]dbus.Variant),
+	}
+	if filepath.IsAbs(opts.IconPath) {
+		// Icons are icon names or URIs.
+		note.AppIcon = (&url.URL{Scheme: "file", Path: opts.IconPath}).String()
+	}
+	switch {
+	case opts.Timeout == 0:
+		// -1 is the default timeout of the server.
+		note.ExpireTimeout = -time.Millisecond
+	case opts.Timeout > 0:
+		note.ExpireTimeout = opts.Timeout
+	}
+	switch opts.Urgency {
+	case UrgencyLow:
+		note.Hints["urgency"] = dbus.MakeVariant(byte(0))
+	case UrgencyNormal:
+		note.Hints["urgency"] = dbus.MakeVariant(byte(1))
+	case UrgencyCritical:
+		note.Hints["urgency"] = dbus.MakeVariant(byte(2))
+	}
+	if opts.Category != "" {
+		note.Hints["category"] = dbus.MakeVariant(opts.Category)
+	}
+	img := opts.Image
+	if img == nil && opts.IconPath == "" {
+		img = opts.Icon
+	}
+	if img != nil {
+		note.Hints["image-data"] = imageData(img)
+	}
+	if opts.Sound != "" {
+		note.Hints["sound-name"] = dbus.MakeVariant(opts.Sound)
+	}
+	if opts.Silent {
+		note.Hints["suppress-sound"] = dbus.MakeVariant(true)
+	}
+	if len(opts.Actions) > 0 {
+		note.Actions = notifyActions(opts.Actions)
+	}
+	return note
+}
+
+// rawImage is the raw image of the image-data hint.
+type rawImage struct {
+	Width, Height, Rowstride int32
+	HasAlpha                 bool
+	BitsPerSample, Channels  int32
+	Data                     []byte
+}
+
+// imageData converts img to the raw image of the image-data hint, in the
+// form (width, height, rowstride, has alpha, bits per sample, channels,
+// data).
+func imageData(img image.Image) dbus.Variant {
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rectangle{Max: b.Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return dbus.MakeVariant(rawImage{
+		Width:         int32(b.Dx()),
+		Height:        int32(b.Dy()),
+		Rowstride:     int32(rgba.Stride),
+		HasAlpha:      true,
+		BitsPerSample: 8,
+		Channels:      4,
+		Data:          rgba.Pix,
+	})
+}
+
+// CreateActionNotification creates a notification with the actions, and the
+// default action invoked by clicking it.
+func (l *dbusNotifier) CreateActionNotification(title, text string, actions ...Action) (Notification, error) {
+	return l.send(notify.Notification{
+		Summary: title,
+		Body:    text,
+		Actions: notifyActions(actions),
+	}, true)
+}
+
+// notifyActions returns the actions of a notification with a button for each
+// action, and the default action invoked by clicking it.
+func notifyActions(actions []Action) []notify.Action {
+	list := []notify.Action{{Key: defaultAction}}
+	for _, a := range actions {
+		list = append(list, notify.Action{Key: a.ID, Label: a.Label})
+	}
+	return list
+}
+
+// send sends note, and records it for Events if track is set.
+func (l *dbusNotifier) send(note notify.Notification, track bool) (Notification, error) {
+	if track {
+		// Hold the lock until the notification is recorded, for the
+		// signal handlers to find it.
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	id, err := l.Notifier.SendNotification(note)
+	if err != nil {
+		return nil, err
+	}
+	n := &dbusNotification{
+		id:           id,
+		dbusNotifier: l,
+	}
+	if track {
+		l.active[id] = n
+	}
+	return n, nil
+}
+
+func (l *dbusNotifier) Events() <-chan Event {
+	return l.events
+}
+
+// onAction handles the ActionInvoked signal, which the server sends for the
+// notifications of every app.
+func (l *dbusNotifier) onAction(s *notify.ActionInvokedSignal) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, ok := l.active[s.ID]
+	if !ok {
+		return
+	}
+	e := Event{Notification: n, Kind: ActionInvoked, Action: s.ActionKey}
+	if s.ActionKey == defaultAction {
+		e = Event{Notification: n, Kind: Clicked}
+	}
+	sendEvent(l.events, e)
+}
+
+// onClosed handles the NotificationClosed signal.
+func (l *dbusNotifier) onClosed(s *notify.NotificationClosedSignal) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, ok := l.active[s.ID]
+	if !ok {
+		return
+	}
+	delete(l.active, s.ID)
+	var reason CloseReason
+	switch s.Reason {
+	case notify.ReasonExpired:
+		reason = ReasonExpired
+	case notify.ReasonDismissedByUser:
+		reason = ReasonDismissed
+	case notify.ReasonClosedByCall:
+		reason = ReasonCancelled
+	}
+	sendEvent(l.events, Event{Notification: n, Kind: Closed, Reason: reason})
+}
+
 func (l dbusNotification) Cancel() error {
 	_, err := l.dbusNotifier.CloseNotification(l.id)
 	return err
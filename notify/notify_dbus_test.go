@@ -0,0 +1,302 @@
+//go:build (linux && !android) || openbsd || freebsd || netbsd
+// +build linux,!android openbsd freebsd netbsd
+
+package notify
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gioui.org/x/internal/dbustest"
+	"github.com/esiqveland/notify"
+	dbus "github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsPath      = "/org/freedesktop/Notifications"
+	notificationsInterface = "org.freedesktop.Notifications"
+)
+
+// fakeServer implements the notification server, recording the actions and
+// hints of the notifications it receives.
+type fakeServer struct {
+	conn *dbus.Conn
+
+	mu      sync.Mutex
+	lastID  uint32
+	actions map[uint32][]string
+	hints   map[uint32]map[string]dbus.Variant
+}
+
+func (s *fakeServer) Notify(appName string, replacesID uint32, appIcon, summary, body string, actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	s.actions[s.lastID] = actions
+	s.hints[s.lastID] = hints
+	return s.lastID, nil
+}
+
+func (s *fakeServer) CloseNotification(id uint32) *dbus.Error {
+	s.emit("NotificationClosed", id, uint32(notify.ReasonClosedByCall))
+	return nil
+}
+
+// emit emits the signal called name.
+func (s *fakeServer) emit(name string, args ...interface{}) {
+	s.conn.Emit(notificationsPath, notificationsInterface+"."+name, args...)
+}
+
+// startServer starts a fake notification server on a private session bus,
+// and returns it with a notifier connected to it.
+func startServer(t *testing.T) (*fakeServer, *dbusNotifier) {
+	t.Helper()
+	dbustest.StartBus(t)
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s := &fakeServer{
+		conn:    conn,
+		actions: make(map[uint32][]string),
+		hints:   make(map[uint32]map[string]dbus.Variant),
+	}
+	if err := conn.Export(s, notificationsPath, notificationsInterface); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := conn.RequestName(notificationsInterface, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("failed to own the server name: %v", err)
+	}
+
+	client, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	l, err := newDBusNotifier(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Notifier.Close() })
+	return s, l
+}
+
+// nextEvent returns the next event of l.
+func nextEvent(t *testing.T, l *dbusNotifier) Event {
+	t.Helper()
+	select {
+	case e := <-l.Events():
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestDBusActionEvents(t *testing.T) {
+	s, l := startServer(t)
+	n, err := l.CreateActionNotification("title", "text", Action{ID: "reply", Label: "Reply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := n.(*dbusNotification).id
+	s.mu.Lock()
+	actions := s.actions[id]
+	s.mu.Unlock()
+	if want := []string{defaultAction, "", "reply", "Reply"}; !slices.Equal(actions, want) {
+		t.Errorf("server received actions %q, want %q", actions, want)
+	}
+	other, err := l.CreateNotification("other", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID := other.(*dbusNotification).id
+
+	// Signals are delivered in order, so the signals for other
+	// notifications are ignored if the next event is of n.
+	tests := []struct {
+		signal string
+		args   []interface{}
+		want   Event
+	}{
+		{"ActionInvoked", []interface{}{id, defaultAction}, Event{Notification: n, Kind: Clicked}},
+		{"ActionInvoked", []interface{}{id, "reply"}, Event{Notification: n, Kind: ActionInvoked, Action: "reply"}},
+		{"NotificationClosed", []interface{}{id, uint32(notify.ReasonDismissedByUser)}, Event{Notification: n, Kind: Closed, Reason: ReasonDismissed}},
+	}
+	for _, test := range tests {
+		s.emit("ActionInvoked", otherID, defaultAction)
+		s.emit("ActionInvoked", id+100, "reply")
+		s.emit(test.signal, test.args...)
+		if got := nextEvent(t, l); got != test.want {
+			t.Errorf("%s%v reported %+v, want %+v", test.signal, test.args, got, test.want)
+		}
+	}
+
+	// Closed notifications are forgotten, and cancelling reports the reason.
+	cancelled, err := l.CreateActionNotification("cancelled", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.emit("ActionInvoked", id, "reply")
+	if err := cancelled.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	want := Event{Notification: cancelled, Kind: Closed, Reason: ReasonCancelled}
+	if got := nextEvent(t, l); got != want {
+		t.Errorf("Cancel reported %+v, want %+v", got, want)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.active) != 0 {
+		t.Errorf("%d closed notifications are still active", len(l.active))
+	}
+}
+
+func TestDBusOptionsWithActions(t *testing.T) {
+	s, l := startServer(t)
+	n, err := l.CreateNotificationWithOptions("title", "text", Options{
+		Urgency: UrgencyCritical,
+		Actions: []Action{{ID: "open", Label: "Open"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := n.(*dbusNotification).id
+	s.mu.Lock()
+	actions, hints := s.actions[id], s.hints[id]
+	s.mu.Unlock()
+	if want := []string{defaultAction, "", "open", "Open"}; !slices.Equal(actions, want) {
+		t.Errorf("server received actions %q, want %q", actions, want)
+	}
+	if got := hints["urgency"].Value(); got != byte(2) {
+		t.Errorf("server received urgency %v, want 2", got)
+	}
+	s.emit("ActionInvoked", id, "open")
+	want := Event{Notification: n, Kind: ActionInvoked, Action: "open"}
+	if got := nextEvent(t, l); got != want {
+		t.Errorf("ActionInvoked reported %+v, want %+v", got, want)
+	}
+}
+
+func TestNotificationWithOptions(t *testing.T) {
+	icon := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	tests := []struct {
+		name    string
+		opts    Options
+		appIcon string
+		timeout time.Duration
+		hints   map[string]interface{}
+		actions []notify.Action
+	}{
+		{
+			name:    "default",
+			timeout: -time.Millisecond,
+			hints:   map[string]interface{}{"urgency": byte(1)},
+		},
+		{
+			name:    "low",
+			opts:    Options{Urgency: UrgencyLow, Timeout: 5 * time.Second},
+			timeout: 5 * time.Second,
+			hints:   map[string]interface{}{"urgency": byte(0)},
+		},
+		{
+			name:    "critical",
+			opts:    Options{Urgency: UrgencyCritical, Timeout: -1, Category: "im.received"},
+			timeout: 0,
+			hints:   map[string]interface{}{"urgency": byte(2), "category": "im.received"},
+		},
+		{
+			name:    "icon name",
+			opts:    Options{IconPath: "mail-unread", Icon: icon, Sound: "message-new-instant"},
+			appIcon: "mail-unread",
+			timeout: -time.Millisecond,
+			hints:   map[string]interface{}{"urgency": byte(1), "sound-name": "message-new-instant"},
+		},
+		{
+			name:    "icon path",
+			opts:    Options{IconPath: "/icons/app icon.png", Image: img, Silent: true},
+			appIcon: "file:///icons/app%20icon.png",
+			timeout: -time.Millisecond,
+			hints:   map[string]interface{}{"urgency": byte(1), "image-data": imageData(img).Value(), "suppress-sound": true},
+		},
+		{
+			name:    "icon image",
+			opts:    Options{Icon: icon, AppName: "app"},
+			timeout: -time.Millisecond,
+			hints:   map[string]interface{}{"urgency": byte(1), "image-data": imageData(icon).Value()},
+		},
+		{
+			name:    "actions",
+			opts:    Options{Actions: []Action{{ID: "reply", Label: "Reply"}}},
+			timeout: -time.Millisecond,
+			hints:   map[string]interface{}{"urgency": byte(1)},
+			actions: []notify.Action{{Key: defaultAction}, {Key: "reply", Label: "Reply"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			note := notificationWithOptions("title", "text", test.opts)
+			if note.Summary != "title" || note.Body != "text" || note.AppName != test.opts.AppName {
+				t.Errorf("got summary %q, body %q and app name %q", note.Summary, note.Body, note.AppName)
+			}
+			if note.AppIcon != test.appIcon {
+				t.Errorf("got icon %q, want %q", note.AppIcon, test.appIcon)
+			}
+			if note.ExpireTimeout != test.timeout {
+				t.Errorf("got timeout %v, want %v", note.ExpireTimeout, test.timeout)
+			}
+			hints := make(map[string]interface{})
+			for k, v := range note.Hints {
+				hints[k] = v.Value()
+			}
+			if !reflect.DeepEqual(hints, test.hints) {
+				t.Errorf("got hints %v, want %v", hints, test.hints)
+			}
+			if !slices.Equal(note.Actions, test.actions) {
+				t.Errorf("got actions %v, want %v", note.Actions, test.actions)
+			}
+		})
+	}
+}
+
+func TestImageData(t *testing.T) {
+	// The image is part of a larger image, with a row stride of 16 bytes
+	// and an origin other than (0, 0).
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	src.Set(1, 1, color.RGBA{R: 0x80, A: 0x80})
+	src.Set(2, 1, color.RGBA{G: 0xff, A: 0xff})
+	src.Set(1, 2, color.RGBA{B: 0x40, A: 0x40})
+	img := src.SubImage(image.Rect(1, 1, 3, 3))
+
+	got, ok := imageData(img).Value().(rawImage)
+	if !ok {
+		t.Fatalf("got image-data of type %T", imageData(img).Value())
+	}
+	want := rawImage{
+		Width:         2,
+		Height:        2,
+		Rowstride:     8,
+		HasAlpha:      true,
+		BitsPerSample: 8,
+		Channels:      4,
+		// The colors aren't premultiplied by alpha.
+		Data: []byte{
+			0xff, 0, 0, 0x80, 0, 0xff, 0, 0xff,
+			0, 0, 0xff, 0x40, 0, 0, 0, 0,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got image-data %+v, want %+v", got, want)
+	}
+	if sig := imageData(img).Signature().String(); sig != "(iiibiiay)" {
+		t.Errorf("got image-data signature %s, want (iiibiiay)", sig)
+	}
+}
@@ -12,3 +12,7 @@ func newNotifier() (Notifier, error) {
 func (unsupported) CreateNotification(title, text string) (Notification, error) {
 	return &noop{}, nil
 }
+
+func (unsupported) CreateNotificationWithOptions(title, text string, _ Options) (Notification, error) {
+	return &noop{}, nil
+}
@@ -4,6 +4,11 @@
 package notify
 
 import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"git.sr.ht/~jackmordaunt/go-toast"
 )
 
@@ -13,7 +18,10 @@ type windowsNotifier struct {
 	icon string
 }
 
-var _ Notifier = (*windowsNotifier)(nil)
+var (
+	_ ActionNotifier  = (*windowsNotifier)(nil)
+	_ OptionsNotifier = (*windowsNotifier)(nil)
+)
 
 func newNotifier() (Notifier, error) {
 	return &windowsNotifier{}, nil
@@ -30,7 +38,153 @@ func (m *windowsNotifier) CreateNotification(title, text string) (Notification,
 	}).Push()
 }
 
+// CreateNotificationWithOptions pushes a notification to windows, honouring
+// the IconPath, Timeout, Sound, Silent, AppName and Actions options. Timeouts
+// over 10 seconds and negative timeouts show the toast for longer. Like
+// those of CreateActionNotification, the clicks on notifications with
+// actions are reported by Events.
+// Note; cancellation is not implemented for notifications without actions.
+func (m *windowsNotifier) CreateNotificationWithOptions(title, text string, opts Options) (Notification, error) {
+	n := &toast.Notification{
+		AppID: title,
+		Title: title,
+		Body:  text,
+		Icon:  m.icon,
+		Audio: opts.Sound,
+	}
+	if opts.AppName != "" {
+		n.AppID = opts.AppName
+	}
+	if opts.IconPath != "" {
+		n.Icon = opts.IconPath
+	}
+	if opts.Timeout < 0 || opts.Timeout > 10*time.Second {
+		n.Duration = toast.Long
+	}
+	if opts.Silent {
+		n.Audio = toast.Silent
+	}
+	if len(opts.Actions) > 0 {
+		return pushActions(n, opts.Actions)
+	}
+	return noop{}, n.Push()
+}
+
 // UseIcon configures an icon to use for notifications, specified as a filepath.
 func (m *windowsNotifier) UseIcon(path string) {
 	m.icon = path
 }
+
+// maxActiveToasts is the number of action notifications reported by the
+// activation callback. Windows doesn't report dismissed or expired toasts, so
+// older toasts are forgotten.
+const maxActiveToasts = 64
+
+// toasts records the action notifications for the activation callback, which
+// is global to the process.
+var toasts struct {
+	once   sync.Once
+	mu     sync.Mutex
+	nextID uint64
+	active map[uint64]*windowsNotification
+	events chan Event
+}
+
+// initToasts sets up the activation callback of action notifications.
+func initToasts() {
+	toasts.once.Do(func() {
+		toasts.active = make(map[uint64]*windowsNotification)
+		toasts.events = make(chan Event, eventBufferSize)
+		toast.SetActivationCallback(onActivation)
+	})
+}
+
+// windowsNotification is an action notification. Windows doesn't report
+// closed toasts, so it's forgotten when it's activated, which closes the
+// toast, when it's cancelled, or once maxActiveToasts newer notifications
+// were created.
+type windowsNotification struct {
+	id uint64
+}
+
+// CreateActionNotification pushes a notification with the actions to
+// windows. Clicks are reported while the app is running, for the last
+// maxActiveToasts notifications, but closing the notification isn't.
+func (m *windowsNotifier) CreateActionNotification(title, text string, actions ...Action) (Notification, error) {
+	return pushActions(&toast.Notification{
+		AppID: title,
+		Title: title,
+		Body:  text,
+		Icon:  m.icon,
+	}, actions)
+}
+
+// pushActions pushes t with a button for each action, and records it for the
+// activation callback.
+func pushActions(t *toast.Notification, actions []Action) (Notification, error) {
+	initToasts()
+	toasts.mu.Lock()
+	toasts.nextID++
+	n := &windowsNotification{id: toasts.nextID}
+	toasts.active[n.id] = n
+	if n.id > maxActiveToasts {
+		delete(toasts.active, n.id-maxActiveToasts)
+	}
+	toasts.mu.Unlock()
+
+	// The activation arguments are the ID of the notification, followed by
+	// the ID of the action, if any.
+	prefix := strconv.FormatUint(n.id, 10) + ":"
+	t.ActivationType = toast.Foreground
+	t.ActivationArguments = prefix
+	for _, a := range actions {
+		t.Actions = append(t.Actions, toast.Action{
+			Type:      toast.Foreground,
+			Content:   a.Label,
+			Arguments: prefix + a.ID,
+		})
+	}
+	if err := t.Push(); err != nil {
+		n.Cancel()
+		return nil, err
+	}
+	return n, nil
+}
+
+func (m *windowsNotifier) Events() <-chan Event {
+	initToasts()
+	return toasts.events
+}
+
+// onActivation reports the activation of a toast.
+func onActivation(args string, _ []toast.UserData) {
+	idStr, action, ok := strings.Cut(args, ":")
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return
+	}
+	toasts.mu.Lock()
+	defer toasts.mu.Unlock()
+	n, ok := toasts.active[id]
+	if !ok {
+		return
+	}
+	// Activating a toast closes it.
+	delete(toasts.active, id)
+	e := Event{Notification: n, Kind: ActionInvoked, Action: action}
+	if action == "" {
+		e = Event{Notification: n, Kind: Clicked}
+	}
+	sendEvent(toasts.events, e)
+}
+
+// Cancel forgets the notification. Note; the toast isn't removed.
+func (n *windowsNotification) Cancel() error {
+	toasts.mu.Lock()
+	defer toasts.mu.Unlock()
+	delete(toasts.active, n.id)
+	return nil
+}
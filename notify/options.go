@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"image"
+	"time"
+)
+
+// Urgency is the urgency level of a notification.
+type Urgency uint8
+
+const (
+	UrgencyNormal Urgency = iota
+	UrgencyLow
+	UrgencyCritical
+)
+
+// Options configures a notification. Platforms ignore the options they don't
+// support:
+//
+//   - Linux honours every option, mapping them to the hints of the
+//     freedesktop.org notification specification.
+//   - Windows honours IconPath, Timeout, Sound, Silent, AppName and Actions.
+//   - macOS, iOS and Android ignore the options.
+type Options struct {
+	// Urgency of the notification. Critical notifications may stay visible
+	// until they're dismissed.
+	Urgency Urgency
+	// Category classifies the notification, such as "email.arrived" or
+	// "im.received".
+	Category string
+	// IconPath is the path of the icon of the notification. On Linux, it
+	// may also be the name of an icon of the icon theme.
+	IconPath string
+	// Icon is the icon of the notification, used if IconPath is empty. On
+	// Linux, it's shown like Image, if Image is nil.
+	Icon image.Image
+	// Image is a large image shown in the notification.
+	Image image.Image
+	// Timeout is how long the notification is shown. Zero means the
+	// platform default, and negative timeouts keep the notification until
+	// it's dismissed.
+	Timeout time.Duration
+	// Sound is the sound played when the notification is shown, such as the
+	// "message-new-instant" sound of the freedesktop.org sound theme on
+	// Linux, or "ms-winsoundevent:Notification.IM" on Windows.
+	Sound string
+	// Silent mutes the sound of the notification.
+	Silent bool
+	// AppName is the name of the app showing the notification.
+	AppName string
+	// Actions are the buttons of the notification, where the notifier is
+	// an ActionNotifier. Like for CreateActionNotification, the clicks on
+	// the notification and its buttons are reported by the Events of the
+	// notifier.
+	Actions []Action
+}
+
+// OptionsNotifier is a notifier that can display notifications configured by
+// Options. The notifiers of every platform implement it, and document the
+// options they honour.
+type OptionsNotifier interface {
+	Notifier
+	CreateNotificationWithOptions(title, text string, opts Options) (Notification, error)
+}
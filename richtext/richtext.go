@@ -2,6 +2,7 @@
 package richtext
 
 import (
+	"image"
 	"image/color"
 	"time"
 
@@ -168,15 +169,41 @@ func (i *InteractiveText) Update(gtx layout.Context) (*InteractiveSpan, Event, b
 
 // SpanStyle describes the appearance of a span of styled text.
 type SpanStyle struct {
-	Font           font.Font
-	Size           unit.Sp
-	Color          color.NRGBA
-	Content        string
+	Font    font.Font
+	Size    unit.Sp
+	Color   color.NRGBA
+	Content string
+	// LetterSpacing is added after every glyph cluster of the span.
+	LetterSpacing unit.Sp
+	// BaselineShift raises the span above the baseline of its line, or
+	// lowers it if negative.
+	BaselineShift unit.Sp
+	// TabularDigits spaces the digits of the span equally. See
+	// styledtext.SpanStyle.TabularDigits.
+	TabularDigits bool
+	// Outline and Shadow are painted behind the glyphs of the span.
+	Outline        styledtext.Outline
+	Shadow         styledtext.Shadow
 	Interactive    bool
 	metadata       map[string]interface{}
 	interactiveIdx int
 }
 
+// styledText returns the styledtext equivalent of the span.
+func (ss SpanStyle) styledText() styledtext.SpanStyle {
+	return styledtext.SpanStyle{
+		Font:          ss.Font,
+		Size:          ss.Size,
+		Color:         ss.Color,
+		Content:       ss.Content,
+		LetterSpacing: ss.LetterSpacing,
+		BaselineShift: ss.BaselineShift,
+		TabularDigits: ss.TabularDigits,
+		Outline:       ss.Outline,
+		Shadow:        ss.Shadow,
+	}
+}
+
 // Set configures a metadata key-value pair on the span that can be
 // retrieved if the span is interacted with. If the provided value
 // is empty, the key will be deleted from the metadata.
@@ -211,16 +238,38 @@ func (ss SpanStyle) DeepCopy() SpanStyle {
 
 // TextStyle presents rich text.
 type TextStyle struct {
-	State      *InteractiveText
-	Styles     []SpanStyle
-	Alignment  text.Alignment
+	State     *InteractiveText
+	Styles    []SpanStyle
+	Alignment text.Alignment
+	// Justify stretches the spaces between words so that every line except
+	// the last line of each paragraph fills the available width.
+	Justify    bool
 	WrapPolicy styledtext.WrapPolicy
+	// Paragraph configures the indentation, spacing and tab stops applied
+	// to every paragraph of the text.
+	Paragraph styledtext.ParagraphStyle
 	// LineHeight controls the distance between the baselines of lines of text.
 	// If zero, a sensible default will be used.
 	LineHeight unit.Sp
 	// LineHeightScale applies a scaling factor to the LineHeight. If zero, a
 	// sensible default will be used.
 	LineHeightScale float32
+	// MaxLines limits the number of lines of text. Zero means no limit.
+	MaxLines int
+	// Truncator is displayed at the end of the final line when text is
+	// truncated to MaxLines. If its Content is empty, an ellipsis is used.
+	// If its Size is zero, it takes the font, size and color of the text
+	// it follows. It may be interactive.
+	Truncator SpanStyle
+	// Cache, if not nil, holds layout results to be reused by subsequent
+	// layouts of identical text.
+	Cache *styledtext.Cache
+	// Hyphenator, if not nil, hyphenates words that don't fit at the end of
+	// a line.
+	Hyphenator *styledtext.Hyphenator
+	// Exclusions are areas, in pixels relative to the top left corner of
+	// the text, that text flows around.
+	Exclusions []image.Rectangle
 	*text.Shaper
 }
 
@@ -235,6 +284,12 @@ func Text(state *InteractiveText, shaper *text.Shaper, styles ...SpanStyle) Text
 
 // Layout renders the TextStyle.
 func (t TextStyle) Layout(gtx layout.Context) layout.Dimensions {
+	return t.LayoutResult(gtx).Dimensions
+}
+
+// LayoutResult is like Layout, but additionally reports details of the
+// resulting layout, such as whether the text was truncated.
+func (t TextStyle) LayoutResult(gtx layout.Context) styledtext.Result {
 	for {
 		_, _, ok := t.State.Update(gtx)
 		if !ok {
@@ -250,22 +305,31 @@ func (t TextStyle) Layout(gtx layout.Context) layout.Dimensions {
 			st.interactiveIdx = numInteractive
 			numInteractive++
 		}
-		styles[i] = styledtext.SpanStyle{
-			Font:    st.Font,
-			Size:    st.Size,
-			Color:   st.Color,
-			Content: st.Content,
-		}
+		styles[i] = st.styledText()
+	}
+	if t.Truncator.Interactive {
+		t.Truncator.interactiveIdx = numInteractive
+		numInteractive++
 	}
 	t.State.resize(numInteractive)
 
 	text := styledtext.Text(t.Shaper, styles...)
 	text.WrapPolicy = t.WrapPolicy
 	text.Alignment = t.Alignment
+	text.Justify = t.Justify
+	text.Paragraph = t.Paragraph
 	text.LineHeight = t.LineHeight
 	text.LineHeightScale = t.LineHeightScale
-	return text.Layout(gtx, func(gtx layout.Context, i int, _ layout.Dimensions) {
-		span := &t.Styles[i]
+	text.MaxLines = t.MaxLines
+	text.Truncator = t.Truncator.styledText()
+	text.Cache = t.Cache
+	text.Hyphenator = t.Hyphenator
+	text.Exclusions = t.Exclusions
+	return text.LayoutResult(gtx, func(gtx layout.Context, i int, _ layout.Dimensions) {
+		span := &t.Truncator
+		if i < len(t.Styles) {
+			span = &t.Styles[i]
+		}
 		if !span.Interactive {
 			return
 		}
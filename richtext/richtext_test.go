@@ -44,3 +44,41 @@ func TestNilInteractiveText(t *testing.T) {
 
 	Text(nil, th.Shaper, spans...).Layout(gtx)
 }
+
+// TestInteractiveTruncator ensures that an interactive truncator is given
+// interactive state.
+func TestInteractiveTruncator(t *testing.T) {
+	th := material.NewTheme()
+	th.Shaper = text.NewShaper(text.WithCollection(gofont.Collection()))
+	spans := []SpanStyle{
+		{
+			Size:    12,
+			Content: "Hello world, this message is far too long to fit on a single line.",
+		},
+	}
+	var ops op.Ops
+	gtx := layout.Context{
+		Constraints: layout.Exact(image.Pt(100, 100)),
+		Metric: unit.Metric{
+			PxPerDp: 1,
+			PxPerSp: 1,
+		},
+		Source: input.Source{},
+		Now:    time.Now(),
+		Ops:    &ops,
+	}
+
+	var state InteractiveText
+	txt := Text(&state, th.Shaper, spans...)
+	txt.MaxLines = 1
+	txt.Truncator = SpanStyle{Content: " more", Interactive: true}
+	if res := txt.LayoutResult(gtx); !res.Truncated {
+		t.Errorf("expected text to be truncated")
+	}
+	if len(state.Spans) != 1 {
+		t.Fatalf("expected 1 interactive span, got %d", len(state.Spans))
+	}
+	if content, _ := state.Spans[0].Content(); content != " more" {
+		t.Errorf("expected interactive truncator content %q, got %q", " more", content)
+	}
+}
@@ -0,0 +1,144 @@
+package styledtext
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// level returns the bidirectional embedding level of the fragment's text in a
+// paragraph with base level base, or -1 if the fragment contains only neutral
+// or weak characters. The shaper already orders text within the fragment, so
+// fragments containing both strong directions are kept at the base level.
+func (f *spanFragment) level(base int) int {
+	var hasL, hasR bool
+	content := f.span.Content
+	for n := 0; n < f.runes && content != ""; n++ {
+		r, sz := utf8.DecodeRuneInString(content)
+		content = content[sz:]
+		props, _ := bidi.LookupRune(r)
+		switch props.Class() {
+		case bidi.L:
+			hasL = true
+		case bidi.R, bidi.AL:
+			hasR = true
+		}
+	}
+	switch {
+	case hasL && hasR:
+		return base
+	case hasR:
+		return 1
+	case hasL:
+		// left-to-right text embedded in a right-to-left paragraph is one
+		// level deeper than the paragraph.
+		return base + base&1
+	default:
+		return -1
+	}
+}
+
+// visualOrder returns the indices of the line's fragments in left to right
+// visual order, following rules N1, N2 and L2 of the Unicode bidirectional
+// algorithm applied to whole fragments.
+func (l line) visualOrder(rtl bool) []int {
+	base := 0
+	if rtl {
+		base = 1
+	}
+	levels := make([]int, len(l.fragments))
+	mixed := rtl
+	for k := range l.fragments {
+		levels[k] = l.fragments[k].level(base)
+		mixed = mixed || levels[k] > 0
+	}
+	order := make([]int, len(l.fragments))
+	for k := range order {
+		order[k] = k
+	}
+	if !mixed {
+		return order
+	}
+
+	// Neutral fragments take the direction of the surrounding text if it
+	// agrees on both sides, and the paragraph direction otherwise.
+	for k := 0; k < len(levels); k++ {
+		if levels[k] >= 0 {
+			continue
+		}
+		end := k
+		for end < len(levels) && levels[end] < 0 {
+			end++
+		}
+		before, after := base, base
+		if k > 0 {
+			before = levels[k-1]
+		}
+		if end < len(levels) {
+			after = levels[end]
+		}
+		level := base
+		if before&1 == after&1 {
+			level = min(before, after)
+		}
+		for ; k < end; k++ {
+			levels[k] = level
+		}
+	}
+
+	// Reverse every sequence of fragments at or above each odd level, from
+	// the highest level down to the lowest odd level.
+	highest, lowestOdd := 0, 1
+	for _, level := range levels {
+		highest = max(highest, level)
+	}
+	for level := highest; level >= lowestOdd; level-- {
+		for k := 0; k < len(order); {
+			if levels[order[k]] < level {
+				k++
+				continue
+			}
+			end := k
+			for end < len(order) && levels[order[end]] >= level {
+				end++
+			}
+			for i, j := k, end-1; i < j; i, j = i+1, j-1 {
+				order[i], order[j] = order[j], order[i]
+			}
+			k = end
+		}
+	}
+	return order
+}
+
+// positions returns the horizontal offset of each of the line's fragments
+// when displayed in visual order. Edge is the left edge of the line in
+// left-to-right paragraphs and its right edge in right-to-left paragraphs.
+// Space preceding a fragment, such as indentation and tabs, stays on the side
+// of the fragment facing the start of the line.
+func (l line) positions(rtl bool, edge int) []int {
+	lead := func(k int) int {
+		if k == 0 {
+			return l.fragments[k].x
+		}
+		prev := l.fragments[k-1]
+		return l.fragments[k].x - (prev.x + prev.width)
+	}
+	order := l.visualOrder(rtl)
+	xs := make([]int, len(l.fragments))
+	x := edge
+	if !rtl {
+		for _, k := range order {
+			x += lead(k)
+			xs[k] = x
+			x += l.fragments[k].width
+		}
+		return xs
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		k := order[i]
+		x -= lead(k) + l.fragments[k].width
+		xs[k] = x
+	}
+	return xs
+}
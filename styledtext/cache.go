@@ -0,0 +1,107 @@
+package styledtext
+
+import (
+	"image"
+	"slices"
+
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+// Cache holds the results of laying out text, so that text that doesn't
+// change between frames isn't shaped and broken into lines again. Like
+// widget state, a Cache should be kept across frames by the caller and
+// used by a single TextStyle at a time.
+//
+// The cached results are discarded whenever the content, styling or
+// exclusions of the text, the layout constraints, the metric or the locale
+// change.
+type Cache struct {
+	key    cacheKey
+	ok     bool
+	ops    op.Ops
+	spans  []placedSpan
+	result Result
+	// digits holds the digits shaped for spans with TabularDigits, which
+	// don't change with the layout.
+	digits map[digitsKey]digitAdvances
+}
+
+// cacheKey holds every input that affects the layout of text.
+type cacheKey struct {
+	styles          []SpanStyle
+	alignment       text.Alignment
+	justify         bool
+	wrapPolicy      WrapPolicy
+	paragraph       ParagraphStyle
+	lineHeight      unit.Sp
+	lineHeightScale float32
+	maxLines        int
+	truncator       SpanStyle
+	hyphenator      *Hyphenator
+	exclusions      []image.Rectangle
+	shaper          *text.Shaper
+	constraints     layout.Constraints
+	metric          unit.Metric
+	locale          system.Locale
+}
+
+// Invalidate discards the cached layout.
+func (c *Cache) Invalidate() {
+	c.ok = false
+}
+
+// valid reports whether the cached layout can be used to lay out t.
+func (c *Cache) valid(gtx layout.Context, t TextStyle) bool {
+	k := &c.key
+	return c.ok &&
+		slices.Equal(k.styles, t.Styles) &&
+		k.alignment == t.Alignment &&
+		k.justify == t.Justify &&
+		k.wrapPolicy == t.WrapPolicy &&
+		k.paragraph.equal(t.Paragraph) &&
+		k.lineHeight == t.LineHeight &&
+		k.lineHeightScale == t.LineHeightScale &&
+		k.maxLines == t.MaxLines &&
+		k.truncator == t.Truncator &&
+		k.hyphenator == t.Hyphenator &&
+		slices.Equal(k.exclusions, t.Exclusions) &&
+		k.shaper == t.Shaper &&
+		k.constraints == gtx.Constraints &&
+		k.metric == gtx.Metric &&
+		k.locale == gtx.Locale
+}
+
+// update lays out t and caches the results.
+func (c *Cache) update(gtx layout.Context, t TextStyle) {
+	c.ops.Reset()
+	cgtx := gtx
+	cgtx.Ops = &c.ops
+	if c.digits == nil {
+		c.digits = make(map[digitsKey]digitAdvances)
+	}
+	t.digitCache = c.digits
+	c.spans, c.result = t.arrange(cgtx, true)
+	c.key = cacheKey{
+		styles:          append(c.key.styles[:0], t.Styles...),
+		alignment:       t.Alignment,
+		justify:         t.Justify,
+		wrapPolicy:      t.WrapPolicy,
+		paragraph:       t.Paragraph,
+		lineHeight:      t.LineHeight,
+		lineHeightScale: t.LineHeightScale,
+		maxLines:        t.MaxLines,
+		truncator:       t.Truncator,
+		hyphenator:      t.Hyphenator,
+		exclusions:      append(c.key.exclusions[:0], t.Exclusions...),
+		shaper:          t.Shaper,
+		constraints:     gtx.Constraints,
+		metric:          gtx.Metric,
+		locale:          gtx.Locale,
+	}
+	c.key.paragraph.TabStops = append(c.key.paragraph.TabStops[:0:0], t.Paragraph.TabStops...)
+	c.ok = true
+}
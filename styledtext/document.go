@@ -0,0 +1,137 @@
+package styledtext
+
+import (
+	"gioui.org/layout"
+)
+
+// Document is a scrollable view of styled text that is too long to lay out
+// at once, such as a log or a book chapter. The text is divided into
+// paragraphs that are laid out lazily: only the paragraphs within the
+// viewport are shaped and painted, and the line breaking of recently visible
+// paragraphs is cached until their content, style or width changes.
+//
+// Like layout.List, a Document holds scroll state and must be kept across
+// frames.
+type Document struct {
+	// List scrolls the paragraphs. Its Axis is always vertical. After each
+	// layout its Position.Length holds the estimated height of the whole
+	// document, which includes the measured heights of paragraphs that have
+	// been laid out.
+	List layout.List
+
+	width int
+	// heights holds the measured height of each paragraph, or -1 if the
+	// paragraph has not been laid out at the current width.
+	heights []int
+	// measured and total are the number and sum of the known heights.
+	measured, total int
+	caches          map[int]*Cache
+	free            []*Cache
+}
+
+// Layout lays out the n paragraphs of the document. The paragraph function
+// returns the spans of the paragraph with the given index, which need not
+// end in a newline. Each paragraph is laid out like a TextStyle with the
+// styling of t; t.Styles, t.MaxLines, t.Cache and t.Exclusions are ignored.
+//
+// The spanFn function, if not nil, is called like the span function of
+// TextStyle.Layout, with the paragraph index and the index of the span within
+// the paragraph.
+func (d *Document) Layout(gtx layout.Context, t TextStyle, n int, paragraph func(i int) []SpanStyle, spanFn func(gtx layout.Context, paragraph, idx int, dims layout.Dimensions)) layout.Dimensions {
+	d.List.Axis = layout.Vertical
+	d.resize(gtx.Constraints.Max.X, n)
+	if d.caches == nil {
+		d.caches = make(map[int]*Cache)
+	}
+	t.MaxLines = 0
+	t.Exclusions = nil
+
+	dims := d.List.Layout(gtx, n, func(gtx layout.Context, i int) layout.Dimensions {
+		pt := t
+		pt.Styles = paragraph(i)
+		pt.Cache = d.cache(i)
+		var fn func(gtx layout.Context, idx int, dims layout.Dimensions)
+		if spanFn != nil {
+			fn = func(gtx layout.Context, idx int, dims layout.Dimensions) {
+				spanFn(gtx, i, idx, dims)
+			}
+		}
+		dims := pt.Layout(gtx, fn)
+		d.measure(i, dims.Size.Y)
+		return dims
+	})
+
+	// Release the caches of paragraphs that are no longer near the
+	// viewport.
+	pos := d.List.Position
+	first, last := pos.First-pos.Count, pos.First+2*pos.Count
+	for i, c := range d.caches {
+		if i < first || i > last {
+			delete(d.caches, i)
+			d.free = append(d.free, c)
+		}
+	}
+
+	d.List.Position.Length = d.length()
+	return dims
+}
+
+// resize prepares the document for laying out n paragraphs in the given
+// width, discarding heights measured at other widths.
+func (d *Document) resize(width, n int) {
+	if width != d.width {
+		d.width = width
+		d.heights = d.heights[:0]
+		d.measured, d.total = 0, 0
+	}
+	if n < len(d.heights) {
+		for _, h := range d.heights[n:] {
+			if h >= 0 {
+				d.measured--
+				d.total -= h
+			}
+		}
+		d.heights = d.heights[:n]
+	}
+	for len(d.heights) < n {
+		d.heights = append(d.heights, -1)
+	}
+}
+
+// cache returns the layout cache of paragraph i.
+func (d *Document) cache(i int) *Cache {
+	if c, ok := d.caches[i]; ok {
+		return c
+	}
+	var c *Cache
+	if n := len(d.free); n > 0 {
+		c = d.free[n-1]
+		d.free = d.free[:n-1]
+		c.Invalidate()
+	} else {
+		c = new(Cache)
+	}
+	d.caches[i] = c
+	return c
+}
+
+// measure records the height of paragraph i.
+func (d *Document) measure(i, height int) {
+	if old := d.heights[i]; old >= 0 {
+		d.total -= old
+	} else {
+		d.measured++
+	}
+	d.heights[i] = height
+	d.total += height
+}
+
+// length estimates the height of the document. Paragraphs that have not been
+// laid out are assumed to have the average height of those that have.
+func (d *Document) length() int {
+	if d.measured == 0 {
+		return 0
+	}
+	unmeasured := len(d.heights) - d.measured
+	return d.total + unmeasured*d.total/d.measured
+}
@@ -0,0 +1,76 @@
+package styledtext
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// Outline describes a stroke painted around the glyphs of a span, behind
+// them, to keep text readable over busy backgrounds.
+type Outline struct {
+	// Width is the distance the outline extends beyond the glyph edges.
+	Width unit.Dp
+	Color color.NRGBA
+}
+
+// Shadow describes a copy of the glyphs of a span painted behind them. A
+// shadow with a zero offset and a positive blur is a glow.
+type Shadow struct {
+	// X and Y offset the shadow from the glyphs.
+	X, Y unit.Dp
+	// Blur is the distance over which the edges of the shadow fade out.
+	// Gio has no blur operation, so the fading is approximated by layered
+	// strokes.
+	Blur  unit.Dp
+	Color color.NRGBA
+}
+
+// maxBlurLayers limits the number of strokes used to approximate the
+// blurred edges of a shadow.
+const maxBlurLayers = 8
+
+// hasEffects reports whether the span paints anything behind its glyphs.
+func (ss SpanStyle) hasEffects() bool {
+	return ss.Shadow.Color.A > 0 || (ss.Outline.Color.A > 0 && ss.Outline.Width > 0)
+}
+
+// paintEffects paints the shadow and outline of the span's glyphs, whose
+// outline is path. It leaves the paint color set to the span's color.
+func (ss SpanStyle) paintEffects(gtx layout.Context, path clip.PathSpec) {
+	if s := ss.Shadow; s.Color.A > 0 {
+		t := op.Offset(image.Pt(gtx.Dp(s.X), gtx.Dp(s.Y))).Push(gtx.Ops)
+		blur := gtx.Dp(s.Blur)
+		layers := min(blur, maxBlurLayers)
+		// The glyphs and each stroke are painted with a fraction of the
+		// shadow's alpha, so that the overlapping strokes are most opaque
+		// near the glyphs.
+		c := s.Color
+		c.A = uint8(int(c.A) / (layers + 1))
+		paint.ColorOp{Color: c}.Add(gtx.Ops)
+		paintClip(gtx, clip.Outline{Path: path}.Op())
+		for i := 1; i <= layers; i++ {
+			width := 2 * float32(blur) * float32(i) / float32(layers)
+			paintClip(gtx, clip.Stroke{Path: path, Width: width}.Op())
+		}
+		t.Pop()
+	}
+	if o := ss.Outline; o.Color.A > 0 && o.Width > 0 {
+		// The stroke is centered on the glyph edges; the fill of the glyphs
+		// covers its inner half.
+		paint.ColorOp{Color: o.Color}.Add(gtx.Ops)
+		paintClip(gtx, clip.Stroke{Path: path, Width: 2 * float32(gtx.Dp(o.Width))}.Op())
+	}
+	paint.ColorOp{Color: ss.Color}.Add(gtx.Ops)
+}
+
+// paintClip paints the current color within the clip.
+func paintClip(gtx layout.Context, c clip.Op) {
+	defer c.Push(gtx.Ops).Pop()
+	paint.PaintOp{}.Add(gtx.Ops)
+}
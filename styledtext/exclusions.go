@@ -0,0 +1,63 @@
+package styledtext
+
+import (
+	"slices"
+
+	"gioui.org/io/system"
+	"gioui.org/layout"
+)
+
+// lineSpace returns the top and the horizontal bounds of the space available
+// to a line of the given height starting at offset y. If the exclusions
+// leave no interval at least as wide as the line is tall, the line is moved
+// below the exclusions overlapping it.
+func (t TextStyle) lineSpace(gtx layout.Context, y, height int) (top, left, right int) {
+	width := gtx.Constraints.Max.X
+	if len(t.Exclusions) == 0 {
+		return y, 0, width
+	}
+	rtl := gtx.Locale.Direction.Progression() == system.TowardOrigin
+	type interval struct{ left, right int }
+	for {
+		free := []interval{{0, width}}
+		below := -1
+		for _, r := range t.Exclusions {
+			r = r.Canon()
+			if r.Empty() || r.Max.Y <= y || r.Min.Y >= y+max(height, 1) {
+				continue
+			}
+			if below < 0 || r.Max.Y < below {
+				below = r.Max.Y
+			}
+			// remove the exclusion from the free intervals.
+			var rest []interval
+			for _, f := range free {
+				if l := min(f.right, r.Min.X); l > f.left {
+					rest = append(rest, interval{f.left, l})
+				}
+				if l := max(f.left, r.Max.X); l < f.right {
+					rest = append(rest, interval{l, f.right})
+				}
+			}
+			free = rest
+		}
+		if below < 0 {
+			return y, 0, width
+		}
+		// pick the widest interval, preferring the one closest to the start
+		// of the line.
+		if rtl {
+			slices.Reverse(free)
+		}
+		best := interval{}
+		for _, f := range free {
+			if f.right-f.left > best.right-best.left {
+				best = f
+			}
+		}
+		if best.right-best.left >= height {
+			return y, best.left, best.right
+		}
+		y = below
+	}
+}
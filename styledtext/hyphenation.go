@@ -0,0 +1,161 @@
+package styledtext
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	//go:embed hyph-en-us.pat
+	englishPatterns string
+	//go:embed hyph-en-us.hyp
+	englishExceptions string
+)
+
+// english is the Hyphenator returned by EnglishHyphenator, parsed on first
+// use.
+var english = sync.OnceValue(func() *Hyphenator {
+	h, err := NewHyphenator(englishPatterns, englishExceptions, 2, 3)
+	if err != nil {
+		panic(err)
+	}
+	return h
+})
+
+// EnglishHyphenator returns a Hyphenator for American English.
+func EnglishHyphenator() *Hyphenator {
+	return english()
+}
+
+// Hyphenator finds the points at which words may be hyphenated using Frank
+// Liang's algorithm, as used by TeX. A Hyphenator is safe for concurrent use.
+type Hyphenator struct {
+	// patterns maps the letters of each pattern to its values.
+	patterns map[string][]uint8
+	// exceptions maps words to their hyphenation points.
+	exceptions map[string][]int
+	// maxLen is the number of runes of the longest pattern.
+	maxLen int
+	// leftMin and rightMin are the minimum number of runes before and after
+	// a hyphenation point.
+	leftMin, rightMin int
+}
+
+// NewHyphenator parses a Hyphenator from patterns and exceptions in the
+// format of TeX's \patterns and \hyphenation commands: whitespace separated
+// patterns such as ".hy3ph", and words with their hyphenation points marked
+// by hyphens, such as "ta-ble". Lines starting with '%' are ignored. Words
+// are never hyphenated with fewer than leftMin runes before or rightMin runes
+// after a hyphen.
+func NewHyphenator(patterns, exceptions string, leftMin, rightMin int) (*Hyphenator, error) {
+	h := &Hyphenator{
+		patterns:   make(map[string][]uint8),
+		exceptions: make(map[string][]int),
+		leftMin:    max(leftMin, 1),
+		rightMin:   max(rightMin, 1),
+	}
+	for _, p := range fields(patterns) {
+		var (
+			letters strings.Builder
+			values  = []uint8{0}
+		)
+		for _, r := range p {
+			if r >= '0' && r <= '9' {
+				if values[len(values)-1] != 0 {
+					return nil, fmt.Errorf("styledtext: invalid hyphenation pattern %q", p)
+				}
+				values[len(values)-1] = uint8(r - '0')
+				continue
+			}
+			letters.WriteRune(unicode.ToLower(r))
+			values = append(values, 0)
+		}
+		if letters.Len() == 0 {
+			return nil, fmt.Errorf("styledtext: invalid hyphenation pattern %q", p)
+		}
+		h.patterns[letters.String()] = values
+		h.maxLen = max(h.maxLen, len(values)-1)
+	}
+	for _, e := range fields(exceptions) {
+		var points []int
+		n := 0
+		for _, r := range e {
+			if r == '-' {
+				points = append(points, n)
+				continue
+			}
+			n++
+		}
+		word := strings.ToLower(strings.ReplaceAll(e, "-", ""))
+		if word == "" {
+			return nil, fmt.Errorf("styledtext: invalid hyphenation exception %q", e)
+		}
+		h.exceptions[word] = points
+	}
+	return h, nil
+}
+
+// fields splits s into whitespace separated fields, ignoring lines that
+// start with '%'.
+func fields(s string) []string {
+	var f []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "%") {
+			continue
+		}
+		f = append(f, strings.Fields(line)...)
+	}
+	return f
+}
+
+// Hyphenate returns the offsets, in runes, at which word may be hyphenated
+// in increasing order.
+func (h *Hyphenator) Hyphenate(word string) []int {
+	n := utf8.RuneCountInString(word)
+	if n < h.leftMin+h.rightMin {
+		return nil
+	}
+	word = strings.ToLower(word)
+	var points []int
+	if exception, ok := h.exceptions[word]; ok {
+		for _, p := range exception {
+			if p >= h.leftMin && p <= n-h.rightMin {
+				points = append(points, p)
+			}
+		}
+		return points
+	}
+
+	// Match the patterns against every substring of the word surrounded
+	// by word boundaries, keeping the highest value at each position.
+	s := "." + word + "."
+	starts := make([]int, 0, n+3)
+	for i := range s {
+		starts = append(starts, i)
+	}
+	starts = append(starts, len(s))
+	values := make([]uint8, len(starts))
+	for i := 0; i < len(starts)-1; i++ {
+		for j := i + 1; j < len(starts) && j-i <= h.maxLen; j++ {
+			pattern, ok := h.patterns[s[starts[i]:starts[j]]]
+			if !ok {
+				continue
+			}
+			for k, v := range pattern {
+				values[i+k] = max(values[i+k], v)
+			}
+		}
+	}
+	// Odd values permit a hyphen. The value before rune p of the word is
+	// at position p+1 of the values.
+	for p := h.leftMin; p <= n-h.rightMin; p++ {
+		if values[p+1]%2 == 1 {
+			points = append(points, p)
+		}
+	}
+	return points
+}
@@ -12,7 +12,7 @@ import (
 	"golang.org/x/image/math/fixed"
 )
 
-// textIterator computes the bounding box of and paints text. This iterator is
+// textIterator computes the bounding box of and collects the glyphs of text. This iterator is
 // specialized to laying out single lines of text.
 type textIterator struct {
 	// viewport is the rectangle of document coordinates that the iterator is
@@ -32,8 +32,6 @@ type textIterator struct {
 	// hasNewline tracks whether the processed glyphs contained a synthetic newline
 	// character.
 	hasNewline bool
-	// lineOff tracks the origin for the glyphs in the current line.
-	lineOff image.Point
 	// padding is the space needed outside of the bounds of the text to ensure no
 	// part of a glyph is clipped.
 	padding image.Rectangle
@@ -48,6 +46,8 @@ type textIterator struct {
 	first bool
 	// baseline tracks the location of the first line of text's baseline.
 	baseline int
+	// glyphs holds the visible glyphs, in the order they were processed.
+	glyphs []text.Glyph
 }
 
 // processGlyph checks whether the glyph is visible within the iterator's configured
@@ -66,6 +66,11 @@ func (it *textIterator) processGlyph(g text.Glyph, ok bool) (_ text.Glyph, visib
 		// glyph on its line. Otherwise the line will seem to have zero size.
 		it.bounds.Min.Y = min(it.bounds.Min.Y, logicalBounds.Min.Y)
 		it.bounds.Max.Y = max(it.bounds.Max.Y, logicalBounds.Max.Y)
+		// Likewise, the line needs a baseline for it to be aligned with
+		// other spans.
+		if !it.first {
+			it.baseline = int(g.Y)
+		}
 		return g, false
 	}
 	it.runes += int(g.Runes)
@@ -121,32 +126,41 @@ func max[T constraints.Ordered](a, b T) T {
 	return b
 }
 
-// paintGlyph buffers up and paints text glyphs. It should be invoked iteratively upon each glyph
-// until it returns false. The line parameter should be a slice with
-// a backing array of sufficient size to buffer multiple glyphs.
-// A modified slice will be returned with each invocation, and is
-// expected to be passed back in on the following invocation.
-// This design is awkward, but prevents the line slice from escaping
-// to the heap.
-func (it *textIterator) paintGlyph(gtx layout.Context, shaper *text.Shaper, glyph text.Glyph, line []text.Glyph) ([]text.Glyph, bool) {
+// appendGlyph processes the glyph and, if it is visible, stores it for
+// painting. It should be invoked iteratively upon each glyph until it returns
+// false.
+func (it *textIterator) appendGlyph(glyph text.Glyph) bool {
 	_, visibleOrBefore := it.processGlyph(glyph, true)
-	if it.visible {
+	if it.visible && glyph.Flags&text.FlagTruncator == 0 {
 		if !it.init {
 			it.firstX = glyph.X
 			it.init = true
 		}
-		if len(line) == 0 {
-			it.lineOff = image.Point{X: (glyph.X - it.firstX).Floor(), Y: int(glyph.Y)}.Sub(it.viewport.Min)
-		}
-		line = append(line, glyph)
+		it.glyphs = append(it.glyphs, glyph)
 	}
-	if glyph.Flags&text.FlagLineBreak > 0 || cap(line)-len(line) == 0 || !visibleOrBefore {
-		t := op.Offset(it.lineOff).Push(gtx.Ops)
-		op := clip.Outline{Path: shaper.Shape(line)}.Op().Push(gtx.Ops)
+	return visibleOrBefore
+}
+
+// paintGlyphs paints glyphs collected by a textIterator, along with the
+// effects of span. The glyphs are positioned relative to originX horizontally
+// and to their line's baseline vertically.
+func paintGlyphs(gtx layout.Context, shaper *text.Shaper, originX fixed.Int26_6, glyphs []text.Glyph, span SpanStyle) {
+	for len(glyphs) > 0 {
+		n := 1
+		for n < len(glyphs) && glyphs[n-1].Flags&text.FlagLineBreak == 0 {
+			n++
+		}
+		line := glyphs[:n]
+		glyphs = glyphs[n:]
+		off := image.Point{X: (line[0].X - originX).Floor(), Y: int(line[0].Y)}
+		t := op.Offset(off).Push(gtx.Ops)
+		path := shaper.Shape(line)
+		if span.hasEffects() {
+			span.paintEffects(gtx, path)
+		}
+		op := clip.Outline{Path: path}.Op().Push(gtx.Ops)
 		paint.PaintOp{}.Add(gtx.Ops)
 		op.Pop()
 		t.Pop()
-		line = line[:0]
 	}
-	return line, visibleOrBefore
 }
@@ -0,0 +1,161 @@
+package styledtext
+
+import (
+	"image"
+
+	"gioui.org/text"
+	"golang.org/x/image/math/fixed"
+)
+
+// Line describes the layout of a line of text. Positions are relative to the
+// top left corner of the text.
+type Line struct {
+	// Y is the top of the line.
+	Y int
+	// Height is the distance from the top of the line to the top of the
+	// following line, excluding paragraph spacing.
+	Height int
+	// Baseline is the vertical position of the line's baseline.
+	Baseline int
+	// X and Width describe the horizontal extent of the line's text.
+	X, Width int
+	// Spans lists the span fragments displayed on the line, in logical
+	// order.
+	Spans []SpanFragment
+}
+
+// SpanFragment describes the portion of a span displayed on a line.
+type SpanFragment struct {
+	// Index is the index of the span in TextStyle.Styles, or len(Styles) for
+	// the truncator.
+	Index int
+	// Start and End are the range of runes of the span's content displayed
+	// by the fragment.
+	Start, End int
+	// Bounds is the logical bounding box of the fragment.
+	Bounds image.Rectangle
+	// Clusters lists the glyph clusters of the fragment, in logical order.
+	Clusters []Cluster
+}
+
+// Cluster describes a glyph cluster: a sequence of glyphs that display one or
+// more runes as a unit.
+type Cluster struct {
+	// Start and End are the range of runes of the span's content represented
+	// by the cluster.
+	Start, End int
+	// Bounds is the logical bounding box of the cluster.
+	Bounds image.Rectangle
+	// RTL reports whether the cluster belongs to right-to-left text, in which
+	// case its leading edge is Bounds.Max.X.
+	RTL bool
+}
+
+// metrics describes the line, whose fragments have been placed at the given
+// positions in a paragraph with the given direction.
+func (l line) metrics(placed []placedSpan, y, height int, rtl bool) Line {
+	m := Line{
+		Y:        y,
+		Height:   height,
+		Baseline: y + l.ascent,
+		Spans:    make([]SpanFragment, len(l.fragments)),
+	}
+	for k, frag := range l.fragments {
+		p := placed[k]
+		bounds := image.Rectangle{Min: p.offset, Max: p.offset.Add(p.size)}
+		if k == 0 {
+			m.X, m.Width = bounds.Min.X, bounds.Dx()
+		} else {
+			right := max(m.X+m.Width, bounds.Max.X)
+			m.X = min(m.X, bounds.Min.X)
+			m.Width = right - m.X
+		}
+		m.Spans[k] = SpanFragment{
+			Index:    frag.span.idx,
+			Start:    frag.span.offset,
+			End:      frag.span.offset + frag.runes,
+			Bounds:   bounds,
+			Clusters: frag.clusters(p.offset, rtl),
+		}
+	}
+	return m
+}
+
+// clusters returns the glyph clusters of the fragment, which is positioned at
+// offset in a paragraph with the given direction.
+func (f *spanFragment) clusters(offset image.Point, rtl bool) []Cluster {
+	var (
+		clusters []Cluster
+		levels   []int
+		minX     fixed.Int26_6
+		maxX     fixed.Int26_6
+		started  bool
+	)
+	base := 0
+	if rtl {
+		base = 1
+	}
+	for _, g := range f.glyphs {
+		if !started || g.X < minX {
+			minX = g.X
+		}
+		if !started || g.X+g.Advance > maxX {
+			maxX = g.X + g.Advance
+		}
+		started = true
+		if g.Flags&text.FlagClusterBreak == 0 {
+			continue
+		}
+		towardOrigin := g.Flags&text.FlagTowardOrigin != 0
+		y := offset.Y + int(g.Y)
+		clusters = append(clusters, Cluster{
+			// Start is the cluster's rune count until the clusters are
+			// ordered logically below.
+			Start: int(g.Runes),
+			Bounds: image.Rectangle{
+				Min: image.Pt(offset.X+(minX-f.firstX).Floor(), y-g.Ascent.Ceil()),
+				Max: image.Pt(offset.X+(maxX-f.firstX).Ceil(), y+g.Descent.Ceil()),
+			},
+			RTL: towardOrigin,
+		})
+		level := base + base&1
+		if towardOrigin {
+			level = 1
+		}
+		levels = append(levels, level)
+		started = false
+	}
+
+	// The clusters are in visual order. Reverse the steps of the
+	// bidirectional reordering to recover their logical order.
+	highest := 0
+	for _, level := range levels {
+		highest = max(highest, level)
+	}
+	for level := 1; level <= highest; level++ {
+		for k := 0; k < len(clusters); {
+			if levels[k] < level {
+				k++
+				continue
+			}
+			end := k
+			for end < len(clusters) && levels[end] >= level {
+				end++
+			}
+			for i, j := k, end-1; i < j; i, j = i+1, j-1 {
+				clusters[i], clusters[j] = clusters[j], clusters[i]
+				levels[i], levels[j] = levels[j], levels[i]
+			}
+			k = end
+		}
+	}
+
+	offsetRunes := f.span.offset
+	for k := range clusters {
+		runes := clusters[k].Start
+		clusters[k].Start = offsetRunes
+		clusters[k].End = offsetRunes + runes
+		offsetRunes += runes
+	}
+	return clusters
+}
@@ -0,0 +1,164 @@
+package styledtext
+
+import (
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"golang.org/x/image/math/fixed"
+)
+
+// ParagraphStyle describes the formatting of paragraphs of styled text. A
+// paragraph ends with each newline in the text.
+type ParagraphStyle struct {
+	// FirstLineIndent is the indentation of the first line of each paragraph.
+	FirstLineIndent unit.Dp
+	// HangingIndent is the indentation of every line of a paragraph after
+	// the first.
+	HangingIndent unit.Dp
+	// SpaceBefore and SpaceAfter are added above and below each paragraph.
+	SpaceBefore, SpaceAfter unit.Dp
+	// TabStops are the positions of tab stops, in increasing order, measured
+	// from the start of the line. A tab character moves the text following
+	// it to the next tab stop. Beyond the final stop, tab stops repeat at the
+	// interval between the final two stops. If empty, tab characters are
+	// shaped like any other character.
+	TabStops []unit.Dp
+}
+
+// equal reports whether p and o describe the same formatting.
+func (p ParagraphStyle) equal(o ParagraphStyle) bool {
+	return p.FirstLineIndent == o.FirstLineIndent &&
+		p.HangingIndent == o.HangingIndent &&
+		p.SpaceBefore == o.SpaceBefore &&
+		p.SpaceAfter == o.SpaceAfter &&
+		slices.Equal(p.TabStops, o.TabStops)
+}
+
+// indent returns the indentation of a line.
+func (p ParagraphStyle) indent(gtx layout.Context, paragraphStart bool) int {
+	if paragraphStart {
+		return gtx.Dp(p.FirstLineIndent)
+	}
+	return gtx.Dp(p.HangingIndent)
+}
+
+// tabStop returns the position reached by advancing n tab stops from x.
+func (p ParagraphStyle) tabStop(gtx layout.Context, x, n int) int {
+	if len(p.TabStops) == 0 {
+		return x
+	}
+	last := gtx.Dp(p.TabStops[len(p.TabStops)-1])
+	interval := last
+	if len(p.TabStops) > 1 {
+		interval -= gtx.Dp(p.TabStops[len(p.TabStops)-2])
+	}
+	for ; n > 0; n-- {
+		next := -1
+		for _, stop := range p.TabStops {
+			if px := gtx.Dp(stop); px > x {
+				next = px
+				break
+			}
+		}
+		if next < 0 {
+			if interval <= 0 {
+				return x
+			}
+			next = last + ((x-last)/interval+1)*interval
+		}
+		x = next
+	}
+	return x
+}
+
+// isJustifiable reports whether the space r may be stretched to justify text.
+// No-break spaces are excluded, as they often separate digits and units.
+func isJustifiable(r rune) bool {
+	switch r {
+	case '\u00a0', '\u2007', '\u202f':
+		return false
+	}
+	return unicode.Is(unicode.Zs, r)
+}
+
+// gaps returns the indices of the glyphs ending justifiable space clusters in
+// the fragment. Spaces at the end of the fragment are excluded if the fragment
+// ends its line. Fragments containing wrapped text have no gaps.
+func (f *spanFragment) gaps(endsLine bool) []int {
+	// The glyphs of right-to-left runs are in visual order, so they cannot
+	// be matched to runes of the content. Spaces are instead recognized as
+	// glyphs without an outline.
+	rtl := false
+	for i, g := range f.glyphs {
+		if g.Flags&text.FlagLineBreak != 0 && i != len(f.glyphs)-1 {
+			return nil
+		}
+		rtl = rtl || g.Flags&text.FlagTowardOrigin != 0
+	}
+	var gaps []int
+	content := f.span.Content
+	trailing := 0
+	for i, g := range f.glyphs {
+		if g.Flags&text.FlagClusterBreak == 0 {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(content)
+		for n := 0; n < int(g.Runes) && content != ""; n++ {
+			_, sz := utf8.DecodeRuneInString(content)
+			content = content[sz:]
+		}
+		if g.Flags&text.FlagParagraphBreak != 0 {
+			continue
+		}
+		space := isJustifiable(r)
+		if rtl {
+			space = g.Runes > 0 && g.Advance > 0 && g.Bounds.Empty()
+		}
+		if space {
+			gaps = append(gaps, i)
+			trailing++
+		} else {
+			trailing = 0
+		}
+	}
+	if endsLine && !rtl {
+		gaps = gaps[:len(gaps)-trailing]
+	}
+	return gaps
+}
+
+// justifiableGaps returns the number of spaces that can be stretched to
+// justify the line.
+func (l line) justifiableGaps() int {
+	gaps := 0
+	for k := range l.fragments {
+		gaps += len(l.fragments[k].gaps(k == len(l.fragments)-1))
+	}
+	return gaps
+}
+
+// justify widens each of the fragment's justifiable spaces by gap and returns
+// the total amount by which the fragment grew.
+func (f *spanFragment) justify(gap fixed.Int26_6, endsLine bool) fixed.Int26_6 {
+	gaps := f.gaps(endsLine)
+	if len(gaps) == 0 {
+		return 0
+	}
+	glyphs := make([]text.Glyph, len(f.glyphs))
+	copy(glyphs, f.glyphs)
+	var shift fixed.Int26_6
+	for i := range glyphs {
+		glyphs[i].X += shift
+		if len(gaps) > 0 && gaps[0] == i {
+			shift += gap
+			gaps = gaps[1:]
+		}
+	}
+	f.glyphs = glyphs
+	f.width += shift.Round()
+	return shift
+}
@@ -0,0 +1,90 @@
+package styledtext
+
+import (
+	"math"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/text"
+	"golang.org/x/image/math/fixed"
+)
+
+// adjust applies the span's digit spacing and letter spacing to the shaped
+// glyphs of res.
+func (t TextStyle) adjust(gtx layout.Context, span SpanStyle, res *spanResults) {
+	// copy the glyphs so that adjustments are never applied twice.
+	glyphs := make([]text.Glyph, len(res.glyphs))
+	copy(glyphs, res.glyphs)
+	res.glyphs = glyphs
+
+	var digits digitAdvances
+	if span.TabularDigits {
+		digits = t.digits(gtx, span)
+	}
+	spacing := fixed.Int26_6(gtx.Metric.PxPerSp * float32(span.LetterSpacing) * 64)
+
+	var shift, widest fixed.Int26_6
+	for i := range glyphs {
+		g := &glyphs[i]
+		g.X += shift
+		if advance, ok := digits.glyphs[g.ID]; ok {
+			// center the digit in a cell as wide as the widest digit.
+			pad := digits.widest - advance
+			g.Offset.X -= pad / 2
+			g.Advance = digits.widest
+			shift += pad
+		}
+		if g.Flags&text.FlagClusterBreak != 0 && g.Flags&text.FlagParagraphBreak == 0 {
+			g.Advance += spacing
+			shift += spacing
+		}
+		if g.Flags&text.FlagLineBreak != 0 {
+			widest = max(widest, shift)
+			shift = 0
+		}
+	}
+	widest = max(widest, shift)
+	res.width = max(res.width+widest.Ceil(), 0)
+}
+
+// digitsKey identifies the digits of a font at a size, as shaped by a shaper.
+type digitsKey struct {
+	shaper *text.Shaper
+	font   font.Font
+	size   fixed.Int26_6
+}
+
+// digitAdvances describes the digits 0-9 of a font.
+type digitAdvances struct {
+	// glyphs maps the glyphs of the digits to their advances.
+	glyphs map[text.GlyphID]fixed.Int26_6
+	// widest is the advance of the widest digit.
+	widest fixed.Int26_6
+}
+
+// digits returns the digits of the span's font, shaping them only once per
+// font and size during a layout, or during the layouts of a Cache.
+func (t TextStyle) digits(gtx layout.Context, span SpanStyle) digitAdvances {
+	key := digitsKey{shaper: t.Shaper, font: span.Font, size: fixed.I(gtx.Sp(span.Size))}
+	if d, ok := t.digitCache[key]; ok {
+		return d
+	}
+	t.Shaper.LayoutString(text.Parameters{
+		Font:     key.font,
+		PxPerEm:  key.size,
+		MaxWidth: math.MaxInt32,
+		Locale:   gtx.Locale,
+	}, "0123456789")
+	d := digitAdvances{glyphs: make(map[text.GlyphID]fixed.Int26_6)}
+	for g, ok := t.Shaper.NextGlyph(); ok; g, ok = t.Shaper.NextGlyph() {
+		if g.Flags&text.FlagParagraphBreak != 0 {
+			continue
+		}
+		d.glyphs[g.ID] = g.Advance
+		d.widest = max(d.widest, g.Advance)
+	}
+	if t.digitCache != nil {
+		t.digitCache[key] = d
+	}
+	return d
+}
@@ -4,9 +4,11 @@ package styledtext
 import (
 	"image"
 	"image/color"
+	"strings"
 	"unicode/utf8"
 
 	"gioui.org/font"
+	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/op/paint"
@@ -21,8 +23,30 @@ type SpanStyle struct {
 	Size    unit.Sp
 	Color   color.NRGBA
 	Content string
+	// LetterSpacing is added after every glyph cluster of the span. It may
+	// be negative to tighten text.
+	LetterSpacing unit.Sp
+	// BaselineShift raises the span above the baseline of its line, or
+	// lowers it if negative, for superscripts and subscripts.
+	BaselineShift unit.Sp
+	// TabularDigits spaces the digits 0-9 of the span as wide as the widest
+	// digit, centering each digit in its cell, so that columns of numbers
+	// line up. It isn't the OpenType "tnum" feature: text.Parameters has no
+	// field for font features, so the digits are re-spaced after shaping,
+	// and the font's own tabular figures aren't used.
+	TabularDigits bool
+	// Outline and Shadow are painted behind the glyphs of the span. They
+	// don't affect the layout of the text.
+	Outline Outline
+	Shadow  Shadow
 
 	idx int
+	// tabs is the number of tab characters that preceded this span's content
+	// in the original text. It is only used when tab stops are configured.
+	tabs int
+	// offset is the position of the span's content within the content of
+	// the span it was derived from, in runes.
+	offset int
 }
 
 // spanShape describes the text shaping of a single span.
@@ -54,11 +78,17 @@ const (
 	// unpleasant results, as it will choose to split words across lines whenever it can. Some
 	// use-cases may still want this, however.
 	WrapGraphemes
+	// WrapHeuristically implements behavior like [gioui.org/text/.WrapHeuristically]. Lines
+	// are broken between words, and a word is only split across lines when it doesn't fit on
+	// a line by itself.
+	WrapHeuristically
 )
 
 func (s WrapPolicy) textPolicy() text.WrapPolicy {
 	switch s {
-	case WrapWords:
+	case WrapWords, WrapHeuristically:
+		// Words that don't fit on a line of their own are broken by
+		// breakWord.
 		return text.WrapWords
 	default:
 		return text.WrapGraphemes
@@ -67,15 +97,45 @@ func (s WrapPolicy) textPolicy() text.WrapPolicy {
 
 // TextStyle presents rich text.
 type TextStyle struct {
-	Styles     []SpanStyle
-	Alignment  text.Alignment
+	Styles    []SpanStyle
+	Alignment text.Alignment
+	// Justify stretches the spaces between words so that every line except
+	// the last line of each paragraph fills the available width. The last
+	// line of each paragraph is positioned according to Alignment.
+	Justify    bool
 	WrapPolicy WrapPolicy
+	// Paragraph configures the indentation, spacing and tab stops applied
+	// to every paragraph of the text.
+	Paragraph ParagraphStyle
 	// LineHeight controls the distance between the baselines of lines of text.
 	// If zero, a sensible default will be used.
 	LineHeight unit.Sp
 	// LineHeightScale applies a scaling factor to the LineHeight. If zero, a
 	// sensible default will be used.
 	LineHeightScale float32
+	// MaxLines limits the number of lines of text. Zero means no limit.
+	MaxLines int
+	// Truncator is displayed at the end of the final line when text is
+	// truncated to MaxLines. If its Content is empty, an ellipsis is used.
+	// If its Size is zero, it takes the font, size and color of the text
+	// it follows. The truncator is reported to the Layout span function with
+	// an index of len(Styles).
+	Truncator SpanStyle
+	// Cache, if not nil, holds layout results to be reused by subsequent
+	// layouts of identical text.
+	Cache *Cache
+	// Hyphenator, if not nil, hyphenates words that don't fit at the end of
+	// a line, inserting a visible hyphen. It is not used with WrapGraphemes.
+	Hyphenator *Hyphenator
+	// Exclusions are areas, in pixels relative to the top left corner of
+	// the text, that text flows around. Lines overlapping an exclusion are
+	// shortened to the widest free interval beside it, or moved below it
+	// when no interval is wide enough.
+	Exclusions []image.Rectangle
+
+	// digitCache holds the digits shaped for spans with TabularDigits. It's
+	// created for each layout, or kept by the Cache.
+	digitCache map[digitsKey]digitAdvances
 
 	*text.Shaper
 }
@@ -89,7 +149,8 @@ func Text(shaper *text.Shaper, styles ...SpanStyle) TextStyle {
 }
 
 type spanResults struct {
-	call             op.CallOp
+	glyphs           []text.Glyph
+	firstX           fixed.Int26_6
 	width            int
 	height           int
 	ascent           int
@@ -98,16 +159,13 @@ type spanResults struct {
 	endedWithNewline bool
 }
 
-func (t TextStyle) iterateSpan(gtx layout.Context, maxWidth int, span SpanStyle, truncate bool) (op.CallOp, textIterator) {
-	var glyphs [32]text.Glyph
+func (t TextStyle) iterateSpan(gtx layout.Context, maxWidth int, span SpanStyle, truncate bool) textIterator {
 	maxLines := 0
 	if truncate {
 		maxLines = 1
 	}
 	lineHeight := fixed.I(gtx.Sp(t.LineHeight))
 	// shape the text of the current span
-	macro := op.Record(gtx.Ops)
-	paint.ColorOp{Color: span.Color}.Add(gtx.Ops)
 	t.Shaper.LayoutString(text.Parameters{
 		Font:            span.Font,
 		PxPerEm:         fixed.I(gtx.Sp(span.Size)),
@@ -124,18 +182,43 @@ func (t TextStyle) iterateSpan(gtx layout.Context, maxWidth int, span SpanStyle,
 		maxLines: 1,
 	}
 
-	line := glyphs[:0]
 	for g, ok := t.Shaper.NextGlyph(); ok; g, ok = t.Shaper.NextGlyph() {
-		line, ok = ti.paintGlyph(gtx, t.Shaper, g, line)
-		if !ok {
+		if !ti.appendGlyph(g) {
 			break
 		}
 	}
-	return macro.Stop(), ti
+	return ti
 }
 
+// layoutSpan shapes as much of the span as fits in maxWidth, applying its
+// letter spacing and digit spacing.
 func (t TextStyle) layoutSpan(gtx layout.Context, maxWidth int, span SpanStyle) spanResults {
-	call, ti := t.iterateSpan(gtx, maxWidth, span, true)
+	res := t.shapeSpan(gtx, maxWidth, span)
+	if span.LetterSpacing != 0 || span.TabularDigits {
+		// The shaper is unaware of the adjustments, so shape the span again
+		// with less space until the adjusted text fits.
+		shapeWidth := maxWidth
+		for {
+			t.adjust(gtx, span, &res)
+			overflow := res.width - maxWidth
+			if overflow <= 0 || shapeWidth == 0 {
+				break
+			}
+			shapeWidth = max(shapeWidth-overflow, 0)
+			next := t.shapeSpan(gtx, shapeWidth, span)
+			if next.runes >= res.runes {
+				break
+			}
+			res = next
+		}
+	}
+	res.ascent += gtx.Sp(span.BaselineShift)
+	return res
+}
+
+// shapeSpan shapes as much of the span as fits in maxWidth.
+func (t TextStyle) shapeSpan(gtx layout.Context, maxWidth int, span SpanStyle) spanResults {
+	ti := t.iterateSpan(gtx, maxWidth, span, true)
 	runesDisplayed := ti.runes
 	multiLine := runesDisplayed < utf8.RuneCountInString(span.Content)
 	endedWithNewline := ti.hasNewline
@@ -149,18 +232,21 @@ func (t TextStyle) layoutSpan(gtx layout.Context, maxWidth int, span SpanStyle)
 		if firstTruncatedRune == '\n' {
 			endedWithNewline = true
 			runesDisplayed++
-		} else if runesDisplayed == 0 && t.WrapPolicy == WrapWords {
+		} else if res, ok := t.hyphenate(gtx, maxWidth, span, runesDisplayed); ok {
+			return res
+		} else if runesDisplayed == 0 && t.WrapPolicy != WrapGraphemes {
 			// If we're only wrapping on word boundaries, we failed to display any runes whatsoever,
 			// and it wasn't due to a hard newline, we need to line-wrap without truncation to discover
 			// the word that doesn't fit on the line.
-			call, ti = t.iterateSpan(gtx, maxWidth, span, false)
+			ti = t.iterateSpan(gtx, maxWidth, span, false)
 			runesDisplayed = ti.runes
 			multiLine = runesDisplayed < utf8.RuneCountInString(span.Content)
 			endedWithNewline = ti.hasNewline
 		}
 	}
 	return spanResults{
-		call:             call,
+		glyphs:           ti.glyphs,
+		firstX:           ti.firstX,
 		width:            ti.bounds.Dx(),
 		height:           ti.bounds.Dy(),
 		ascent:           ti.baseline,
@@ -170,164 +256,477 @@ func (t TextStyle) layoutSpan(gtx layout.Context, maxWidth int, span SpanStyle)
 	}
 }
 
-// Layout renders the TextStyle.
-//
-// The spanFn function, if not nil, gets called for each span after it has been
-// drawn, with the offset set to the span's top left corner. This can be used to
-// set up input handling, for example.
-//
-// The context's maximum constraint is set to the span's dimensions, while the
-// dims argument additionally provides the text's baseline. The idx argument is
-// the span's index in TextStyle.Styles. The function may get called multiple
-// times with the same index if a span has to be broken across multiple lines.
-func (t TextStyle) Layout(gtx layout.Context, spanFn func(gtx layout.Context, idx int, dims layout.Dimensions)) layout.Dimensions {
-	spans := make([]SpanStyle, len(t.Styles))
-	copy(spans, t.Styles)
-	for i := range spans {
-		spans[i].idx = i
+// spanFragment is the portion of a span that is displayed on a single line.
+type spanFragment struct {
+	// span is the styling of the fragment. Its content starts with the
+	// first rune displayed by the fragment.
+	span SpanStyle
+	// x is the offset of the fragment from the start of the line.
+	x int
+	spanResults
+}
+
+// line is a line of text built from span fragments.
+type line struct {
+	fragments []spanFragment
+	// width is the offset of the end of the final fragment from the
+	// start of the line, including any indentation.
+	width int
+	// ascent and descent are the largest distances from the baseline to
+	// the top and bottom of the fragments of the line.
+	ascent, descent int
+	// y is the offset of the top of the line from the top of the text.
+	y int
+	// left and right bound the horizontal interval available to the line.
+	left, right int
+	// paragraphStart and paragraphEnd report whether the line is the first
+	// or last line of a paragraph.
+	paragraphStart, paragraphEnd bool
+}
+
+// spans returns a copy of t.Styles prepared for layout.
+func (t TextStyle) spans() []SpanStyle {
+	spans := make([]SpanStyle, 0, len(t.Styles))
+	tabs := 0
+	for i, span := range t.Styles {
+		span.idx = i
+		if len(t.Paragraph.TabStops) == 0 {
+			spans = append(spans, span)
+			continue
+		}
+		// Split the span around its tab characters; the tabs are replaced
+		// by the offset to the next tab stop during layout.
+		offset := 0
+		for k, part := range strings.Split(span.Content, "\t") {
+			if k > 0 {
+				tabs++
+				offset++
+			}
+			if part == "" {
+				continue
+			}
+			span.Content = part
+			span.tabs = tabs
+			span.offset = offset
+			tabs = 0
+			offset += utf8.RuneCountInString(part)
+			spans = append(spans, span)
+		}
 	}
+	return spans
+}
 
-	// Compute the effective line height following the same logic as
-	// text.Shaper.layoutParagraph: use LineHeight if set, otherwise
-	// fall back to the largest span size, then scale by LineHeightScale
-	// (defaulting to 1.2 if zero).
-	lineHeightScale := t.LineHeightScale
-	lineHeightPx := gtx.Sp(t.LineHeight)
-	if lineHeightScale == 0 {
-		lineHeightScale = 1.2
+// layoutLines breaks the text into lines no wider than the maximum constraint
+// where possible, truncating it to t.MaxLines lines. It reports whether the
+// text was truncated.
+func (t TextStyle) layoutLines(gtx layout.Context) ([]line, bool) {
+	spans := t.spans()
+	if t.MaxLines <= 0 {
+		lines, _ := t.breakLines(gtx, spans, 0, 0, true, 0)
+		return lines, false
 	}
 
+	// Break all but the final permitted line normally, then check whether
+	// the text fits in the final line.
+	var lines []line
+	rest := spans
+	if t.MaxLines > 1 {
+		lines, rest = t.breakLines(gtx, spans, 0, 0, true, t.MaxLines-1)
+	}
+	if len(rest) == 0 {
+		return lines, false
+	}
+	y, paragraphStart := 0, true
+	if len(lines) > 0 {
+		prev := lines[len(lines)-1]
+		y, paragraphStart = t.lineBottom(gtx, prev), prev.paragraphEnd
+	}
+	final, more := t.breakLines(gtx, rest, y, 0, paragraphStart, 1)
+	if len(more) == 0 {
+		return append(lines, final...), false
+	}
+
+	// Break the final line again, leaving room for the truncator.
+	last := final[0].fragments[len(final[0].fragments)-1].span
+	truncator := t.layoutSpan(gtx, gtx.Constraints.Max.X, t.truncator(last))
+	final, _ = t.breakLines(gtx, rest, y, truncator.width, paragraphStart, 1)
+	l := &final[0]
+	last = l.fragments[len(l.fragments)-1].span
+	span := t.truncator(last)
+	truncator = t.layoutSpan(gtx, gtx.Constraints.Max.X, span)
+	l.fragments = append(l.fragments, spanFragment{
+		span:        span,
+		x:           l.width,
+		spanResults: truncator,
+	})
+	l.width += truncator.width
+	l.ascent = max(l.ascent, truncator.ascent)
+	l.descent = max(l.descent, truncator.height-truncator.ascent)
+	l.paragraphEnd = true
+	return append(lines, final...), true
+}
+
+// truncator returns the span to display at the end of truncated text, whose
+// final span is last.
+func (t TextStyle) truncator(last SpanStyle) SpanStyle {
+	span := t.Truncator
+	if span.Content == "" {
+		span.Content = "…"
+		span.Size = 0
+	}
+	if span.Size == 0 {
+		span.Font = last.Font
+		span.Size = last.Size
+		span.Color = last.Color
+	}
+	span.idx = len(t.Styles)
+	span.tabs = 0
+	return span
+}
+
+// breakLines breaks spans into lines starting at offset y from the top of the
+// text. Each line is kept no wider than the space left beside the exclusions
+// minus reserve, where possible. If maxLines is positive, it stops after that
+// many lines and returns the spans holding the remaining content. The
+// paragraphStart parameter reports whether the first line starts a paragraph.
+func (t TextStyle) breakLines(gtx layout.Context, spans []SpanStyle, y, reserve int, paragraphStart bool, maxLines int) ([]line, []SpanStyle) {
+	// copy the spans, as they will be modified while breaking lines.
+	spans = append([]SpanStyle(nil), spans...)
+
 	var (
-		lineDims       image.Point
-		lineAscent     int
-		overallSize    image.Point
-		lineShapes     []spanShape
-		lineStartIndex int
+		lines          []line
+		current        line
+		lineX          int
+		maxWidth       int
+		lineHasContent bool
 	)
+	// The height of a line is only known once it has been broken, so the
+	// space beside the exclusions is found using the height of the previous
+	// line, or the size of the first span for the first line.
+	height := 0
+	if len(spans) > 0 {
+		height = t.lineAdvance(gtx, gtx.Sp(spans[0].Size))
+	}
+	startLine := func(paragraphStart bool) {
+		if paragraphStart {
+			y += gtx.Dp(t.Paragraph.SpaceBefore)
+		}
+		current = line{paragraphStart: paragraphStart}
+		current.y, current.left, current.right = t.lineSpace(gtx, y, height)
+		lineX = t.Paragraph.indent(gtx, paragraphStart)
+		maxWidth = max(current.right-current.left-reserve, 0)
+		lineHasContent = false
+	}
+	startLine(paragraphStart)
 
 	for i := 0; i < len(spans); i++ {
 		// grab the next span
 		span := spans[i]
 
+		spanX := lineX
+		if span.tabs > 0 {
+			spanX = t.Paragraph.tabStop(gtx, spanX, span.tabs)
+		}
+
 		// constrain the width of the line to the remaining space
-		maxWidth := gtx.Constraints.Max.X - lineDims.X
+		spanWidth := max(maxWidth-spanX, 0)
 
-		res := t.layoutSpan(gtx, maxWidth, span)
+		res := t.layoutSpan(gtx, spanWidth, span)
+		if !lineHasContent && res.width > spanWidth && t.WrapPolicy == WrapHeuristically {
+			// The first word of the span doesn't fit even on a line of its
+			// own.
+			res = t.breakWord(gtx, spanWidth, span, res)
+		}
 
 		// forceToNextLine handles the case in which the first segment of the new span does not fit
 		// AND there is already content on the current line. If there is no content on the line,
 		// we should display the content that doesn't fit anyway, as it won't fit on the next
 		// line either.
-		forceToNextLine := lineDims.X > 0 && res.width > maxWidth
+		forceToNextLine := lineHasContent && res.width > spanWidth
 
 		if !forceToNextLine {
 			// store the text shaping results for the line
-			lineShapes = append(lineShapes, spanShape{
-				offset: image.Point{X: lineDims.X},
-				size:   image.Point{X: res.width, Y: res.height},
-				call:   res.call,
-				ascent: res.ascent,
+			current.fragments = append(current.fragments, spanFragment{
+				span:        span,
+				x:           spanX,
+				spanResults: res,
 			})
-			// update the dimensions of the current line
-			lineDims.X += res.width
-			if lineDims.Y < res.height {
-				lineDims.Y = res.height
+			// update the dimensions of the current line. Spans share a
+			// common baseline, so the line is as tall as its largest
+			// ascent plus its largest descent.
+			lineX = spanX + res.width
+			lineHasContent = lineHasContent || res.width > 0
+			if current.ascent < res.ascent {
+				current.ascent = res.ascent
 			}
-			if lineAscent < res.ascent {
-				lineAscent = res.ascent
+			if descent := res.height - res.ascent; current.descent < descent {
+				current.descent = descent
 			}
+		}
+
+		// if we are breaking the current span across lines or we are on the
+		// last span, finish the line.
+		if res.multiLine || res.endedWithNewline || i == len(spans)-1 || forceToNextLine {
+			current.width = lineX
+			current.paragraphEnd = !forceToNextLine && (res.endedWithNewline || (i == len(spans)-1 && !res.multiLine))
+			lines = append(lines, current)
+
+			// reset line shaping data
+			height = t.lineAdvance(gtx, current.ascent+current.descent)
+			y = t.lineBottom(gtx, current)
+			startLine(current.paragraphEnd)
+		}
 
-			// update the width of the overall text
-			if overallSize.X < lineDims.X {
-				overallSize.X = lineDims.X
+		// if the current span breaks across lines
+		if res.multiLine && !forceToNextLine {
+			// ensure the spans slice has room for another span
+			spans = append(spans, SpanStyle{})
+			// shift existing spans further
+			for k := len(spans) - 1; k > i+1; k-- {
+				spans[k] = spans[k-1]
 			}
+			// synthesize and insert a new span
+			span.Content = span.Content[runeOffset(span.Content, res.runes):]
+			span.offset += res.runes
+			// the tabs preceding the span have already been applied.
+			span.tabs = 0
+			spans[i+1] = span
+		} else if forceToNextLine {
+			i--
+		}
 
+		if maxLines > 0 && len(lines) == maxLines {
+			return lines, spans[i+1:]
 		}
+	}
+	return lines, nil
+}
 
-		// if we are breaking the current span across lines or we are on the
-		// last span, lay out all of the spans for the line.
-		if res.multiLine || res.endedWithNewline || i == len(spans)-1 || forceToNextLine {
-			lineMacro := op.Record(gtx.Ops)
-			for i, shape := range lineShapes {
-				// lay out this span
-				span = spans[i+lineStartIndex]
-				shape.offset.Y = overallSize.Y
-				span.Layout(gtx, shape)
-
-				if spanFn == nil {
-					continue
+// Layout renders the TextStyle.
+//
+// The spanFn function, if not nil, gets called for each span after it has been
+// drawn, with the offset set to the span's top left corner. This can be used to
+// set up input handling, for example.
+//
+// The context's maximum constraint is set to the span's dimensions, while the
+// dims argument additionally provides the text's baseline. The idx argument is
+// the span's index in TextStyle.Styles. The function may get called multiple
+// times with the same index if a span has to be broken across multiple lines.
+//
+// Spans of different sizes on the same line are aligned on a common
+// baseline. The returned dimensions report the baseline of the first line.
+//
+// The text direction of the context's locale determines the base direction
+// of each paragraph: Start alignment, indentation and tab stops are measured
+// from the right edge of the text in right-to-left locales. The spans on a
+// line are displayed in the visual order given by the Unicode bidirectional
+// algorithm, so right-to-left spans embedded in left-to-right text (and vice
+// versa) appear in reading order.
+func (t TextStyle) Layout(gtx layout.Context, spanFn func(gtx layout.Context, idx int, dims layout.Dimensions)) layout.Dimensions {
+	return t.layout(gtx, spanFn, false).Dimensions
+}
+
+// Result describes laid out text.
+type Result struct {
+	layout.Dimensions
+	// Truncated reports whether text was omitted to satisfy MaxLines.
+	Truncated bool
+	// Lines describes each line of the text, from top to bottom. It must
+	// not be modified, as it may be shared with a Cache.
+	Lines []Line
+}
+
+// LayoutResult is like Layout, but additionally reports details of the
+// resulting layout, including the position of every line and glyph cluster.
+func (t TextStyle) LayoutResult(gtx layout.Context, spanFn func(gtx layout.Context, idx int, dims layout.Dimensions)) Result {
+	return t.layout(gtx, spanFn, true)
+}
+
+// layout lays out the text, computing the metrics of its lines if metrics is
+// set or a cache is used.
+func (t TextStyle) layout(gtx layout.Context, spanFn func(gtx layout.Context, idx int, dims layout.Dimensions), metrics bool) Result {
+	var (
+		spans []placedSpan
+		res   Result
+	)
+	if c := t.Cache; c != nil {
+		if !c.valid(gtx, t) {
+			c.update(gtx, t)
+		}
+		spans, res = c.spans, c.result
+	} else {
+		spans, res = t.arrange(gtx, metrics)
+	}
+
+	for _, span := range spans {
+		span.call.Add(gtx.Ops)
+
+		if spanFn == nil {
+			continue
+		}
+		offStack := op.Offset(span.offset).Push(gtx.Ops)
+		fnGtx := gtx
+		fnGtx.Constraints.Min = image.Point{}
+		fnGtx.Constraints.Max = span.size
+		spanFn(fnGtx, span.idx, layout.Dimensions{Size: span.size, Baseline: span.ascent})
+		offStack.Pop()
+	}
+	return res
+}
+
+// placedSpan is a span fragment that has been positioned and painted.
+type placedSpan struct {
+	idx    int
+	offset image.Point
+	size   image.Point
+	ascent int
+	// call paints the fragment.
+	call op.CallOp
+}
+
+// arrange lays out and records the painting of the text, returning the
+// painted span fragments. The metrics parameter controls whether the result
+// describes the lines of the text.
+func (t TextStyle) arrange(gtx layout.Context, metrics bool) ([]placedSpan, Result) {
+	if t.digitCache == nil {
+		t.digitCache = make(map[digitsKey]digitAdvances)
+	}
+	lines, truncated := t.layoutLines(gtx)
+	rtl := gtx.Locale.Direction.Progression() == system.TowardOrigin
+
+	var (
+		placed        []placedSpan
+		lineMetrics   []Line
+		overallSize   image.Point
+		firstBaseline = -1
+	)
+	for _, l := range lines {
+		// Compute padding to align line within the space available to it. If
+		// the line is longer than can be displayed then padding is implicitly
+		// limited to zero. The padding is measured from the start of the line,
+		// which is its right edge in right-to-left locales.
+		var pad int
+		var gap fixed.Int26_6
+		if space := l.right - l.left - l.width; space > 0 {
+			if t.Justify && !l.paragraphEnd {
+				if gaps := l.justifiableGaps(); gaps > 0 {
+					gap = fixed.I(space) / fixed.Int26_6(gaps)
 				}
-				offStack := op.Offset(shape.offset).Push(gtx.Ops)
-				fnGtx := gtx
-				fnGtx.Constraints.Min = image.Point{}
-				fnGtx.Constraints.Max = shape.size
-				spanFn(fnGtx, span.idx, layout.Dimensions{Size: shape.size, Baseline: shape.ascent})
-				offStack.Pop()
 			}
-			lineCall := lineMacro.Stop()
-
-			// Compute padding to align line. If the line is longer than can be displayed then padding is implicitly
-			// limited to zero.
-			finalShape := lineShapes[len(lineShapes)-1]
-			lineWidth := finalShape.offset.X + finalShape.size.X
-			var pad int
-			if lineWidth < gtx.Constraints.Max.X {
+			if gap == 0 {
 				switch t.Alignment {
 				case text.Start:
 					pad = 0
 				case text.Middle:
-					pad = (gtx.Constraints.Max.X - lineWidth) / 2
+					pad = space / 2
 				case text.End:
-					pad = gtx.Constraints.Max.X - lineWidth
+					pad = space
 				}
 			}
+		}
 
-			stack := op.Offset(image.Pt(pad, 0)).Push(gtx.Ops)
-			lineCall.Add(gtx.Ops)
-			stack.Pop()
-
-			// reset line shaping data and update overall vertical dimensions
-			lineShapes = lineShapes[:0]
-			// When we have lineHeight set, use it as the line height.
-			// Otherwise, use the largest span size, then scale by LineHeightScale.
-			effectiveLineHeight := lineDims.Y
-			if t.LineHeight != 0 {
-				effectiveLineHeight = lineHeightPx
+		// stretch the spaces of justified lines
+		lineWidth := l.width
+		if gap > 0 {
+			var shift fixed.Int26_6
+			for k := range l.fragments {
+				frag := &l.fragments[k]
+				frag.x += shift.Round()
+				shift += frag.justify(gap, k == len(l.fragments)-1)
 			}
-			effectiveLineHeight = int(float32(effectiveLineHeight) * lineHeightScale)
-			overallSize.Y += effectiveLineHeight
-			lineDims = image.Point{}
-			lineAscent = 0
+			lineWidth += shift.Round()
 		}
 
-		// if the current span breaks across lines
-		if res.multiLine && !forceToNextLine {
-			// mark where the next line to be laid out starts
-			lineStartIndex = i + 1
-
-			// ensure the spans slice has room for another span
-			spans = append(spans, SpanStyle{})
-			// shift existing spans further
-			for k := len(spans) - 1; k > i+1; k-- {
-				spans[k] = spans[k-1]
-			}
-			// synthesize and insert a new span
-			byteLen := 0
-			for i := 0; i < res.runes; i++ {
-				_, n := utf8.DecodeRuneInString(span.Content[byteLen:])
-				byteLen += n
+		// position the fragments in visual order, starting from the
+		// right edge of the line in right-to-left paragraphs.
+		edge := l.left + pad
+		if rtl {
+			edge = max(l.right, l.left+lineWidth) - pad
+		}
+		xs := l.positions(rtl, edge)
+
+		// lay out the spans of the line
+		lineStart := len(placed)
+		for k, frag := range l.fragments {
+			span := frag.span
+			shape := spanShape{
+				// shift the span down so that its baseline lines up with
+				// the tallest span on the line.
+				offset: image.Point{X: xs[k], Y: l.y + l.ascent - frag.ascent},
+				size:   image.Point{X: frag.width, Y: frag.height},
+				call:   t.paintFragment(gtx, frag),
+				ascent: frag.ascent,
 			}
-			span.Content = span.Content[byteLen:]
-			spans[i+1] = span
-		} else if forceToNextLine {
-			// mark where the next line to be laid out starts
-			lineStartIndex = i
-			i--
-		} else if res.endedWithNewline {
-			// mark where the next line to be laid out starts
-			lineStartIndex = i + 1
+			macro := op.Record(gtx.Ops)
+			span.Layout(gtx, shape)
+			placed = append(placed, placedSpan{
+				idx:    span.idx,
+				offset: shape.offset,
+				size:   shape.size,
+				ascent: shape.ascent,
+				call:   macro.Stop(),
+			})
+		}
+
+		// update the width of the overall text, measured from its start
+		// edge.
+		extent := l.left + lineWidth
+		if rtl {
+			extent = gtx.Constraints.Max.X - l.right + lineWidth
+		}
+		if overallSize.X < extent {
+			overallSize.X = extent
+		}
+		if firstBaseline < 0 {
+			firstBaseline = l.y + l.ascent
+		}
+
+		// update overall vertical dimensions.
+		if metrics {
+			height := t.lineAdvance(gtx, l.ascent+l.descent)
+			lineMetrics = append(lineMetrics, l.metrics(placed[lineStart:], l.y, height, rtl))
 		}
+		if bottom := t.lineBottom(gtx, l); overallSize.Y < bottom {
+			overallSize.Y = bottom
+		}
+	}
+
+	dims := layout.Dimensions{Size: gtx.Constraints.Constrain(overallSize)}
+	if firstBaseline >= 0 {
+		dims.Baseline = dims.Size.Y - firstBaseline
 	}
+	return placed, Result{Dimensions: dims, Truncated: truncated, Lines: lineMetrics}
+}
 
-	return layout.Dimensions{Size: gtx.Constraints.Constrain(overallSize)}
+// lineAdvance returns the distance from the top of a line to the top of the
+// following line, given the natural height of the line. Following the same
+// logic as text.Shaper.layoutParagraph, it uses LineHeight if set, otherwise
+// the natural height, then scales by LineHeightScale (defaulting to 1.2 if
+// zero).
+func (t TextStyle) lineAdvance(gtx layout.Context, natural int) int {
+	if t.LineHeight != 0 {
+		natural = gtx.Sp(t.LineHeight)
+	}
+	scale := t.LineHeightScale
+	if scale == 0 {
+		scale = 1.2
+	}
+	return int(float32(natural) * scale)
+}
+
+// lineBottom returns the offset from the top of the text to the end of l,
+// including the space after l if it ends a paragraph.
+func (t TextStyle) lineBottom(gtx layout.Context, l line) int {
+	bottom := l.y + t.lineAdvance(gtx, l.ascent+l.descent)
+	if l.paragraphEnd {
+		bottom += gtx.Dp(t.Paragraph.SpaceAfter)
+	}
+	return bottom
+}
+
+// paintFragment records the operations to paint the glyphs of frag.
+func (t TextStyle) paintFragment(gtx layout.Context, frag spanFragment) op.CallOp {
+	macro := op.Record(gtx.Ops)
+	paintGlyphs(gtx, t.Shaper, frag.firstX, frag.glyphs, frag.span)
+	return macro.Stop()
 }
@@ -2,11 +2,15 @@ package styledtext
 
 import (
 	"image"
+	"image/color"
+	"slices"
 	"testing"
 
 	"gioui.org/app"
 	"gioui.org/font"
 	"gioui.org/font/gofont"
+	"gioui.org/gpu/headless"
+	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/text"
@@ -159,3 +163,582 @@ func TestStyledtextNewlines(t *testing.T) {
 		})
 	}
 }
+
+// TestStyledtextBaseline ensures that spans of different sizes on the same line
+// share a baseline, and that the baseline is reported in the dimensions.
+func TestStyledtextBaseline(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(1000, 1000))
+
+	large := Text(shaper, SpanStyle{Size: 24, Content: "b"})
+	largeDims := large.Layout(gtx, nil)
+	if largeDims.Baseline == 0 {
+		t.Fatalf("expected non-zero baseline for a single line")
+	}
+
+	mixed := Text(shaper, SpanStyle{Size: 12, Content: "a"}, SpanStyle{Size: 24, Content: "b"})
+	mixedDims := mixed.Layout(gtx, nil)
+
+	if got, expected := mixedDims.Size.Y-mixedDims.Baseline, largeDims.Size.Y-largeDims.Baseline; got != expected {
+		t.Errorf("expected baseline %d from the top, got %d", expected, got)
+	}
+	if mixedDims.Size.Y != largeDims.Size.Y {
+		t.Errorf("expected mixed line height %d, got %d", largeDims.Size.Y, mixedDims.Size.Y)
+	}
+}
+
+// TestStyledtextJustify ensures that justified lines fill the available width,
+// except for the last line of a paragraph.
+func TestStyledtextJustify(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(1000, 1000))
+
+	txt := Text(shaper, SpanStyle{Size: 12, Content: "The quick brown fox "}, SpanStyle{Size: 16, Content: "jumps over the lazy dog."})
+	line := txt.Layout(gtx, nil)
+	// One pixel less than the whole text wraps only the last word, leaving
+	// at least its width as slack on the first line.
+	gtx.Constraints.Max.X = line.Size.X - 1
+	ragged := txt.Layout(gtx, nil)
+	if ragged.Size.Y <= line.Size.Y || ragged.Size.Y >= 3*line.Size.Y {
+		t.Fatalf("expected the text to wrap onto two lines, got height %d for line height %d", ragged.Size.Y, line.Size.Y)
+	}
+	if ragged.Size.X >= gtx.Constraints.Max.X {
+		t.Fatalf("expected ragged text narrower than %d, got %d", gtx.Constraints.Max.X, ragged.Size.X)
+	}
+	txt.Justify = true
+	justified := txt.Layout(gtx, nil)
+	if justified.Size.X != gtx.Constraints.Max.X {
+		t.Errorf("expected justified text to be %d wide, got %d", gtx.Constraints.Max.X, justified.Size.X)
+	}
+	if justified.Size.Y != ragged.Size.Y {
+		t.Errorf("expected justification to preserve the height %d, got %d", ragged.Size.Y, justified.Size.Y)
+	}
+
+	single := Text(shaper, SpanStyle{Size: 12, Content: "a b"})
+	single.Justify = true
+	if dims := single.Layout(gtx, nil); dims.Size.X == gtx.Constraints.Max.X {
+		t.Errorf("expected the last line of a paragraph not to be justified")
+	}
+}
+
+// TestStyledtextParagraph checks paragraph indentation, spacing and tab stops.
+func TestStyledtextParagraph(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(1000, 1000))
+
+	plain := Text(shaper, SpanStyle{Size: 12, Content: "a\nb"})
+	plainDims := plain.Layout(gtx, nil)
+
+	indented := plain
+	indented.Paragraph.FirstLineIndent = 20
+	if dims := indented.Layout(gtx, nil); dims.Size.X != plainDims.Size.X+20 {
+		t.Errorf("expected first line indent to widen text to %d, got %d", plainDims.Size.X+20, dims.Size.X)
+	}
+
+	spaced := plain
+	spaced.Paragraph.SpaceBefore = 3
+	spaced.Paragraph.SpaceAfter = 7
+	if dims := spaced.Layout(gtx, nil); dims.Size.Y != plainDims.Size.Y+2*10 {
+		t.Errorf("expected paragraph spacing to grow text to %d, got %d", plainDims.Size.Y+2*10, dims.Size.Y)
+	}
+
+	var fragments []int
+	tabbed := Text(shaper, SpanStyle{Size: 12, Content: "a\tb"}, SpanStyle{Size: 12, Content: "\t\tc"})
+	tabbed.Paragraph.TabStops = []unit.Dp{100, 150}
+	tabbed.Layout(gtx, func(gtx layout.Context, idx int, dims layout.Dimensions) {
+		fragments = append(fragments, idx)
+	})
+	if len(fragments) != 3 {
+		t.Fatalf("expected tabs to split text into 3 fragments, got %d", len(fragments))
+	}
+	if dims := tabbed.Layout(gtx, nil); dims.Size.X <= 200 || dims.Size.X > 250 {
+		t.Errorf("expected the final tab to reach the repeated stop at 200, got width %d", dims.Size.X)
+	}
+}
+
+// TestStyledtextBidi ensures that spans are displayed in visual order and that
+// right-to-left locales align text from the right.
+func TestStyledtextBidi(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(1000, 1000))
+
+	txt := Text(shaper,
+		SpanStyle{Size: 12, Content: "abc "},
+		SpanStyle{Size: 12, Content: "אבג "},
+		SpanStyle{Size: 12, Content: "דהו"},
+		SpanStyle{Size: 12, Content: " def"},
+	)
+	lines, _ := txt.layoutLines(gtx)
+	if len(lines) != 1 {
+		t.Fatalf("expected a single line, got %d", len(lines))
+	}
+	if got, expected := lines[0].visualOrder(false), []int{0, 2, 1, 3}; !slices.Equal(got, expected) {
+		t.Errorf("expected left-to-right visual order %v, got %v", expected, got)
+	}
+	if got, expected := lines[0].visualOrder(true), []int{3, 2, 1, 0}; !slices.Equal(got, expected) {
+		t.Errorf("expected right-to-left visual order %v, got %v", expected, got)
+	}
+
+	gtx.Locale = system.Locale{Language: "he", Direction: system.RTL}
+	single := Text(shaper, SpanStyle{Size: 12, Content: "אבג"})
+	lines, _ = single.layoutLines(gtx)
+	xs := lines[0].positions(true, gtx.Constraints.Max.X)
+	if expected := gtx.Constraints.Max.X - lines[0].width; xs[0] != expected {
+		t.Errorf("expected right-to-left text to start at %d, got %d", expected, xs[0])
+	}
+}
+
+// TestStyledtextMaxLines ensures that text is truncated to MaxLines and that the
+// truncator is displayed.
+func TestStyledtextMaxLines(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(100, 1000))
+
+	spans := []SpanStyle{
+		{Size: 12, Content: "The quick brown fox jumps over "},
+		{Size: 12, Content: "the lazy dog. The quick brown fox jumps over the lazy dog."},
+	}
+	full := Text(shaper, spans...).LayoutResult(gtx, nil)
+	if full.Truncated {
+		t.Errorf("expected text without MaxLines not to be truncated")
+	}
+
+	oneLine := Text(shaper, SpanStyle{Size: 12, Content: "a"}).Layout(gtx, nil)
+
+	txt := Text(shaper, spans...)
+	txt.MaxLines = 2
+	sawTruncator := false
+	res := txt.LayoutResult(gtx, func(gtx layout.Context, idx int, dims layout.Dimensions) {
+		if idx == len(spans) {
+			sawTruncator = true
+		}
+	})
+	if !res.Truncated {
+		t.Errorf("expected text to be truncated")
+	}
+	if !sawTruncator {
+		t.Errorf("expected the truncator to be laid out")
+	}
+	if res.Size.Y != 2*oneLine.Size.Y || res.Size.Y >= full.Size.Y {
+		t.Errorf("expected truncated text to be %d tall, got %d", 2*oneLine.Size.Y, res.Size.Y)
+	}
+	if res.Size.X > gtx.Constraints.Max.X {
+		t.Errorf("expected truncated text to fit within %d, got %d", gtx.Constraints.Max.X, res.Size.X)
+	}
+
+	txt.MaxLines = 100
+	if res := txt.LayoutResult(gtx, nil); res.Truncated || res.Size != full.Size {
+		t.Errorf("expected text within MaxLines to be unaffected")
+	}
+}
+
+// TestStyledtextCache ensures that cached layouts match uncached ones and are
+// invalidated by changes to the text or constraints.
+func TestStyledtextCache(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(100, 1000))
+
+	var cache Cache
+	txt := Text(shaper, benchmarkSpans()...)
+	uncached := txt.Layout(gtx, nil)
+	txt.Cache = &cache
+	for i := 0; i < 2; i++ {
+		var spans int
+		dims := txt.Layout(gtx, func(gtx layout.Context, idx int, dims layout.Dimensions) { spans++ })
+		if dims != uncached {
+			t.Errorf("layout %d: expected cached dimensions %v, got %v", i, uncached, dims)
+		}
+		if spans == 0 {
+			t.Errorf("layout %d: expected span callbacks", i)
+		}
+	}
+
+	txt.Styles = append(txt.Styles, SpanStyle{Size: 12, Content: "\nmore"})
+	if dims := txt.Layout(gtx, nil); dims.Size.Y <= uncached.Size.Y {
+		t.Errorf("expected changed content to invalidate the cache")
+	}
+	gtx.Constraints.Max.X = 50
+	txt.Cache = nil
+	narrow := txt.Layout(gtx, nil)
+	txt.Cache = &cache
+	if dims := txt.Layout(gtx, nil); dims != narrow {
+		t.Errorf("expected changed constraints to invalidate the cache: expected %v, got %v", narrow, dims)
+	}
+}
+
+func benchmarkSpans() []SpanStyle {
+	var spans []SpanStyle
+	for i := 0; i < 10; i++ {
+		spans = append(spans,
+			SpanStyle{Size: 12, Content: "The quick brown fox "},
+			SpanStyle{Size: 16, Font: font.Font{Weight: font.Bold}, Content: "jumps over "},
+			SpanStyle{Size: 12, Font: font.Font{Style: font.Italic}, Content: "the lazy dog.\n"},
+		)
+	}
+	return spans
+}
+
+func benchmarkLayout(b *testing.B, cache *Cache) {
+	var ops op.Ops
+	shaper := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	txt := Text(shaper, benchmarkSpans()...)
+	txt.Cache = cache
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ops.Reset()
+		gtx := app.NewContext(&ops, app.FrameEvent{
+			Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1},
+			Size:   image.Point{X: 300, Y: 1000},
+		})
+		txt.Layout(gtx, func(gtx layout.Context, idx int, dims layout.Dimensions) {})
+	}
+}
+
+func BenchmarkLayout(b *testing.B) {
+	benchmarkLayout(b, nil)
+}
+
+func BenchmarkLayoutCached(b *testing.B) {
+	benchmarkLayout(b, new(Cache))
+}
+
+// TestStyledtextMetrics checks the line and glyph cluster metrics reported by
+// LayoutResult.
+func TestStyledtextMetrics(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(1000, 1000))
+
+	txt := Text(shaper, SpanStyle{Size: 12, Content: "ab"}, SpanStyle{Size: 16, Content: "cd\nef"})
+	res := txt.LayoutResult(gtx, nil)
+	if len(res.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(res.Lines))
+	}
+	first, second := res.Lines[0], res.Lines[1]
+	if second.Y != first.Y+first.Height {
+		t.Errorf("expected second line at %d, got %d", first.Y+first.Height, second.Y)
+	}
+	if first.Baseline != res.Size.Y-res.Baseline {
+		t.Errorf("expected first line baseline %d, got %d", res.Size.Y-res.Baseline, first.Baseline)
+	}
+	if len(first.Spans) != 2 || first.Spans[0].Index != 0 || first.Spans[1].Index != 1 {
+		t.Fatalf("expected first line to hold both spans, got %+v", first.Spans)
+	}
+	if len(second.Spans) != 1 || second.Spans[0].Index != 1 || second.Spans[0].Start != 3 || second.Spans[0].End != 5 {
+		t.Errorf("expected second line to hold runes 3-5 of span 1, got %+v", second.Spans)
+	}
+
+	var x int
+	for _, span := range first.Spans {
+		for k, c := range span.Clusters {
+			if c.Start != k || c.End != k+1 {
+				t.Errorf("span %d: expected cluster %d to hold rune %d, got %d-%d", span.Index, k, k, c.Start, c.End)
+			}
+			if c.Bounds.Min.X < x {
+				t.Errorf("span %d: cluster %d starts at %d, before the previous cluster at %d", span.Index, k, c.Bounds.Min.X, x)
+			}
+			if c.Bounds.Min.Y > first.Baseline || c.Bounds.Max.Y < first.Baseline {
+				t.Errorf("span %d: cluster %d does not contain the baseline", span.Index, k)
+			}
+			x = c.Bounds.Min.X
+		}
+	}
+}
+
+// TestStyledtextSpanAdjustments checks letter spacing, baseline shifts and
+// tabular digits.
+func TestStyledtextSpanAdjustments(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(1000, 1000))
+
+	plain := Text(shaper, SpanStyle{Size: 12, Content: "LABEL"}).Layout(gtx, nil)
+	tracked := Text(shaper, SpanStyle{Size: 12, Content: "LABEL", LetterSpacing: 2}).Layout(gtx, nil)
+	if expected := plain.Size.X + 5*2; tracked.Size.X < expected-1 || tracked.Size.X > expected+1 {
+		t.Errorf("expected letter spacing to widen text to %d, got %d", expected, tracked.Size.X)
+	}
+
+	narrow := gtx
+	narrow.Constraints.Max.X = plain.Size.X + 2
+	if res := Text(shaper, SpanStyle{Size: 12, Content: "LABEL LABEL", LetterSpacing: 2}).LayoutResult(narrow, nil); len(res.Lines) != 2 || res.Size.X > narrow.Constraints.Max.X {
+		t.Errorf("expected letter spaced text to wrap within %d, got %d lines %d wide", narrow.Constraints.Max.X, len(res.Lines), res.Size.X)
+	}
+
+	base := Text(shaper, SpanStyle{Size: 12, Content: "x"}, SpanStyle{Size: 8, Content: "2"}).Layout(gtx, nil)
+	super := Text(shaper, SpanStyle{Size: 12, Content: "x"}, SpanStyle{Size: 8, Content: "2", BaselineShift: 8}).Layout(gtx, nil)
+	if super.Size.Y <= base.Size.Y || super.Size.Y-super.Baseline <= base.Size.Y-base.Baseline {
+		t.Errorf("expected a raised superscript to push the baseline down, got %v and %v", base, super)
+	}
+
+	ones := Text(shaper, SpanStyle{Size: 12, Content: "1111", TabularDigits: true}).Layout(gtx, nil)
+	eights := Text(shaper, SpanStyle{Size: 12, Content: "8888", TabularDigits: true}).Layout(gtx, nil)
+	if ones.Size.X != eights.Size.X {
+		t.Errorf("expected tabular digits to have equal widths, got %d and %d", ones.Size.X, eights.Size.X)
+	}
+
+	cached := Text(shaper,
+		SpanStyle{Size: 12, Content: "12", TabularDigits: true},
+		SpanStyle{Size: 12, Content: "34", TabularDigits: true},
+		SpanStyle{Size: 16, Content: "56", TabularDigits: true},
+	)
+	cached.Cache = new(Cache)
+	cached.Layout(gtx, nil)
+	if n := len(cached.Cache.digits); n != 2 {
+		t.Errorf("expected the digits of 2 sizes to be kept by the cache, got %d", n)
+	}
+}
+
+// TestDocument ensures that a Document only lays out visible paragraphs and
+// estimates its height.
+func TestDocument(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(300, 200))
+	lineHeight := Text(shaper, SpanStyle{Size: 12, Content: "a"}).Layout(gtx, nil).Size.Y
+
+	const paragraphs = 50000
+	var doc Document
+	laidOut := make(map[int]bool)
+	paragraph := func(i int) []SpanStyle {
+		laidOut[i] = true
+		return []SpanStyle{{Size: 12, Content: "A line of a very long log."}}
+	}
+	doc.Layout(gtx, Text(shaper), paragraphs, paragraph, nil)
+
+	if max := gtx.Constraints.Max.Y/lineHeight + 2; len(laidOut) > max {
+		t.Errorf("expected at most %d paragraphs to be laid out, got %d", max, len(laidOut))
+	}
+	if expected := paragraphs * lineHeight; doc.List.Position.Length != expected {
+		t.Errorf("expected estimated length %d, got %d", expected, doc.List.Position.Length)
+	}
+
+	doc.List.Position.First = paragraphs / 2
+	doc.Layout(gtx, Text(shaper), paragraphs, paragraph, nil)
+	if !laidOut[paragraphs/2] {
+		t.Errorf("expected scrolled to paragraph to be laid out")
+	}
+	if len(doc.caches) > 3*doc.List.Position.Count {
+		t.Errorf("expected caches of invisible paragraphs to be released, got %d caches", len(doc.caches))
+	}
+}
+
+// TestStyledtextExclusions ensures that text flows around exclusions.
+func TestStyledtextExclusions(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(200, 1000))
+	content := "The quick brown fox jumps over the lazy dog. The quick brown fox jumps over the lazy dog."
+
+	txt := Text(shaper, SpanStyle{Size: 12, Content: content})
+	txt.Exclusions = []image.Rectangle{image.Rect(0, 0, 60, 30)}
+	res := txt.LayoutResult(gtx, nil)
+	if len(res.Lines) < 3 {
+		t.Fatalf("expected at least 3 lines, got %d", len(res.Lines))
+	}
+	for i, l := range res.Lines {
+		beside := l.Y < 30
+		if beside && (l.X < 60 || l.X+l.Width > 200) {
+			t.Errorf("line %d: expected line beside exclusion within [60, 200], got [%d, %d]", i, l.X, l.X+l.Width)
+		}
+		if !beside && l.X != 0 {
+			t.Errorf("line %d: expected line below exclusion to start at 0, got %d", i, l.X)
+		}
+	}
+	if res.Lines[len(res.Lines)-1].Y < 30 {
+		t.Errorf("expected text to continue below the exclusion")
+	}
+
+	txt.Exclusions = []image.Rectangle{image.Rect(0, 0, 200, 50)}
+	res = txt.LayoutResult(gtx, nil)
+	if y := res.Lines[0].Y; y != 50 {
+		t.Errorf("expected full width exclusion to push the first line to 50, got %d", y)
+	}
+	plain := Text(shaper, SpanStyle{Size: 12, Content: content}).Layout(gtx, nil)
+	if res.Size.Y != plain.Size.Y+50 {
+		t.Errorf("expected text to be %d tall, got %d", plain.Size.Y+50, res.Size.Y)
+	}
+}
+
+func TestHyphenate(t *testing.T) {
+	h := EnglishHyphenator()
+	for _, tc := range []struct {
+		word   string
+		points []int
+	}{
+		// Hyphenated by the patterns, ignoring case.
+		{word: "algorithm", points: []int{2, 4}},
+		{word: "Algorithm", points: []int{2, 4}},
+		{word: "computer", points: []int{3}},
+		// Hyphenated as listed in the exceptions, overriding the patterns.
+		// "project" is listed without hyphens.
+		{word: "hyphenation", points: []int{2, 6, 7}},
+		{word: "table", points: []int{2}},
+		{word: "project", points: nil},
+		// Too short to hyphenate.
+		{word: "a", points: nil},
+	} {
+		if got := h.Hyphenate(tc.word); !slices.Equal(got, tc.points) {
+			t.Errorf("Hyphenate(%q) = %v, want %v", tc.word, got, tc.points)
+		}
+	}
+	if _, err := NewHyphenator("a1b2", "", 1, 1); err != nil {
+		t.Errorf("unexpected error parsing valid pattern: %v", err)
+	}
+	if _, err := NewHyphenator("a12b", "", 1, 1); err == nil {
+		t.Errorf("expected error parsing invalid pattern")
+	}
+}
+
+// TestStyledtextWrapping ensures that words are broken only when they don't
+// fit on a line of their own, and hyphenated when possible.
+func TestStyledtextWrapping(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(60, 1000))
+
+	long := Text(shaper, SpanStyle{Size: 12, Content: "a supercalifragilisticexpialidocious word"})
+	if res := long.LayoutResult(gtx, nil); res.Lines[1].Width <= gtx.Constraints.Max.X {
+		t.Errorf("expected WrapWords to let the long word overflow")
+	}
+	long.WrapPolicy = WrapHeuristically
+	res := long.LayoutResult(gtx, nil)
+	for i, l := range res.Lines {
+		if l.Width > gtx.Constraints.Max.X {
+			t.Errorf("line %d: expected WrapHeuristically to fit within %d, got %d", i, gtx.Constraints.Max.X, l.Width)
+		}
+	}
+	if end := res.Lines[0].Spans[0].End; end != 2 {
+		t.Errorf("expected the long word to start on a line of its own, first line ended at %d", end)
+	}
+
+	gtx.Constraints.Max.X = 100
+	plain := Text(shaper, SpanStyle{Size: 12, Content: "The quick hyphenation of words"})
+	hyphenated := plain
+	hyphenated.Hyphenator = EnglishHyphenator()
+	plainRes := plain.LayoutResult(gtx, nil)
+	res = hyphenated.LayoutResult(gtx, nil)
+	if res.Lines[0].Spans[0].End <= plainRes.Lines[0].Spans[0].End {
+		t.Errorf("expected hyphenation to fit more text on the first line")
+	}
+	if end := res.Lines[0].Spans[0].End; end != len("The quick hy") && end != len("The quick hyphen") {
+		t.Errorf("expected first line to end at a hyphenation point, ended at %d", end)
+	}
+	if w := res.Lines[0].Width; w > gtx.Constraints.Max.X {
+		t.Errorf("expected hyphenated line to fit within %d, got %d", gtx.Constraints.Max.X, w)
+	}
+}
+
+// TestStyledtextEffects ensures that outlines and shadows don't affect the
+// layout of text.
+func TestStyledtextEffects(t *testing.T) {
+	gtx, shaper := testContext(image.Pt(200, 1000))
+	gtx.Metric = unit.Metric{PxPerDp: 2, PxPerSp: 2}
+
+	plain := SpanStyle{Size: 12, Content: "Readable text over imagery"}
+	effects := plain
+	effects.Outline = Outline{Width: 1, Color: color.NRGBA{A: 255}}
+	effects.Shadow = Shadow{X: 1, Y: 1, Blur: 4, Color: color.NRGBA{A: 128}}
+	if plain.hasEffects() || !effects.hasEffects() {
+		t.Errorf("expected only the span with an outline and shadow to have effects")
+	}
+	if glow := (SpanStyle{Shadow: Shadow{Blur: 2, Color: color.NRGBA{A: 255}}}); !glow.hasEffects() {
+		t.Errorf("expected a glow to be painted")
+	}
+
+	want := Text(shaper, plain).Layout(gtx, nil)
+	if got := Text(shaper, effects).Layout(gtx, nil); got != want {
+		t.Errorf("expected effects not to change dimensions %v, got %v", want, got)
+	}
+}
+
+// TestStyledtextEffectsPainted renders the effects in distinct colors, and
+// finds them around the ink of the plain glyphs.
+func TestStyledtextEffectsPainted(t *testing.T) {
+	shaper := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	red := color.NRGBA{R: 255, A: 255}
+	green := color.NRGBA{G: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	glyphs := SpanStyle{Size: 40, Color: red, Content: "HI"}
+	ink := inkBounds(renderSpans(t, shaper, glyphs), red)
+	if ink.Empty() {
+		t.Fatalf("expected the glyphs to be painted")
+	}
+
+	outlined := glyphs
+	outlined.Outline = Outline{Width: 2, Color: blue}
+	img := renderSpans(t, shaper, outlined)
+	if got := inkBounds(img, blue); got.Empty() || !got.In(ink.Inset(-3)) || got.Dx() <= ink.Dx() || got.Dy() <= ink.Dy() {
+		t.Errorf("expected the outline to surround the glyphs at %v within 2 pixels, got %v", ink, got)
+	}
+	if got := inkBounds(img, red); got != ink {
+		t.Errorf("expected the glyphs to be painted over the outline at %v, got %v", ink, got)
+	}
+
+	shadowed := glyphs
+	shadowed.Shadow = Shadow{X: 10, Y: 5, Color: green}
+	img = renderSpans(t, shaper, shadowed)
+	if got, want := inkBounds(img, green), ink.Add(image.Pt(10, 5)); got.Max != want.Max || got.Min.X < want.Min.X || got.Min.Y < want.Min.Y {
+		t.Errorf("expected the shadow to be offset to %v, got %v", want, got)
+	}
+	if got := inkBounds(img, red); got != ink {
+		t.Errorf("expected the glyphs to be painted over the shadow at %v, got %v", ink, got)
+	}
+
+	blurred := shadowed
+	blurred.Shadow.Blur = 3
+	if got, sharp := inkBounds(renderSpans(t, shaper, blurred), green), inkBounds(img, green); !got.In(sharp.Inset(-4)) || got.Dx() <= sharp.Dx() || got.Dy() <= sharp.Dy() {
+		t.Errorf("expected the blur to extend the shadow at %v by up to 3 pixels, got %v", sharp, got)
+	}
+}
+
+// testContext returns a context for laying out text in size, with 1 pixel
+// per dp, and a shaper using the Go fonts.
+func testContext(size image.Point) (layout.Context, *text.Shaper) {
+	gtx := app.NewContext(new(op.Ops), app.FrameEvent{
+		Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Size:   size,
+	})
+	gtx.Constraints.Min = image.Point{}
+	return gtx, text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+}
+
+// renderSpans renders the spans 20 pixels from the corner of an otherwise
+// transparent image, skipping the test if no GPU is available.
+func renderSpans(t *testing.T, shaper *text.Shaper, spans ...SpanStyle) *image.RGBA {
+	t.Helper()
+	size := image.Point{X: 200, Y: 100}
+	w, err := headless.NewWindow(size.X, size.Y)
+	if err != nil {
+		t.Skipf("failed to create headless window, skipping: %v", err)
+	}
+	defer w.Release()
+	ops := new(op.Ops)
+	gtx := app.NewContext(ops, app.FrameEvent{
+		Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Size:   size,
+	})
+	gtx.Constraints.Min = image.Point{}
+	off := op.Offset(image.Pt(20, 20)).Push(ops)
+	Text(shaper, spans...).Layout(gtx, nil)
+	off.Pop()
+	if err := w.Frame(ops); err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	if err := w.Screenshot(img); err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// inkBounds returns the bounds of the pixels of img painted mostly in the
+// color c.
+func inkBounds(img *image.RGBA, c color.NRGBA) image.Rectangle {
+	var bounds image.Rectangle
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			p := img.RGBAAt(x, y)
+			if dominant(p.R, p.G, p.B) != dominant(c.R, c.G, c.B) || p.A < 128 {
+				continue
+			}
+			bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	return bounds
+}
+
+// dominant returns the index of the largest of the channels r, g, b.
+func dominant(r, g, b uint8) int {
+	switch {
+	case r > g && r > b:
+		return 0
+	case g > b:
+		return 1
+	default:
+		return 2
+	}
+}
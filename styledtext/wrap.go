@@ -0,0 +1,80 @@
+package styledtext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+)
+
+// hyphenate attempts to fit the start of the word beginning at rune start of
+// the span within maxWidth after the preceding content, followed by a hyphen.
+// It reports whether the word could be hyphenated.
+func (t TextStyle) hyphenate(gtx layout.Context, maxWidth int, span SpanStyle, start int) (spanResults, bool) {
+	if t.Hyphenator == nil || t.WrapPolicy == WrapGraphemes {
+		return spanResults{}, false
+	}
+	head := span.Content[:runeOffset(span.Content, start)]
+	word := span.Content[len(head):]
+	if end := strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		word = word[:end]
+	}
+	points := t.Hyphenator.Hyphenate(word)
+	// Try the hyphenation points from the last to the first, keeping as
+	// much of the word on the line as possible.
+	for k := len(points) - 1; k >= 0; k-- {
+		hyphenated := span
+		hyphenated.Content = head + word[:runeOffset(word, points[k])] + "-"
+		ti := t.iterateSpan(gtx, maxWidth, hyphenated, true)
+		if ti.runes < utf8.RuneCountInString(hyphenated.Content) {
+			continue
+		}
+		for _, g := range ti.glyphs {
+			if g.Flags&text.FlagTowardOrigin != 0 {
+				// The hyphen can't be told apart from the glyphs of
+				// right-to-left text.
+				return spanResults{}, false
+			}
+		}
+		// The hyphen doesn't represent any rune of the content.
+		ti.glyphs[len(ti.glyphs)-1].Runes = 0
+		return spanResults{
+			glyphs:    ti.glyphs,
+			firstX:    ti.firstX,
+			width:     ti.bounds.Dx(),
+			height:    ti.bounds.Dy(),
+			ascent:    ti.baseline,
+			runes:     start + points[k],
+			multiLine: true,
+		}, true
+	}
+	return spanResults{}, false
+}
+
+// breakWord breaks the first word of span, which doesn't fit within maxWidth
+// on a line of its own, between graphemes. The res parameter holds the
+// results of laying out the span without breaking the word.
+func (t TextStyle) breakWord(gtx layout.Context, maxWidth int, span SpanStyle, res spanResults) spanResults {
+	head := span
+	head.Content = span.Content[:runeOffset(span.Content, res.runes)]
+	t.WrapPolicy = WrapGraphemes
+	broken := t.layoutSpan(gtx, maxWidth, head)
+	if broken.runes == 0 || broken.runes >= res.runes {
+		return res
+	}
+	broken.multiLine = true
+	broken.endedWithNewline = false
+	return broken
+}
+
+// runeOffset returns the byte offset of rune n of s.
+func runeOffset(s string, n int) int {
+	off := 0
+	for ; n > 0 && off < len(s); n-- {
+		_, sz := utf8.DecodeRuneInString(s[off:])
+		off += sz
+	}
+	return off
+}